@@ -0,0 +1,250 @@
+package aircrafttest
+
+import (
+	"context"
+	"errors"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// The number of goroutines used to test concurrent access to a lookup.
+const CONFORMANCE_WORKERS int = 8
+
+// ConformanceOptions defines the lookup, and the data, tested by `RunConformance`.
+type ConformanceOptions struct {
+	// NewLookup returns a new instance of the `aircraft.Lookup` implementation being tested.
+	NewLookup func(context.Context) (aircraft.Lookup, error)
+	// Codes is a list of codes known to exist in the lookup.
+	Codes []string
+	// Missing is a list of codes known not to exist in the lookup.
+	Missing []string
+	// NewRecord returns a new record, and a code which that record can be found by, for the i-th call. Codes should be unique
+	// across calls (and across tests, since some implementations share state between instances). If nil then `Append` is not tested.
+	NewRecord func(i int) (string, interface{})
+}
+
+// RunConformance runs the suite of tests that every `aircraft.Lookup` implementation is expected to pass:
+//
+//	Find: Every code in `opts.Codes` returns one or more results.
+//	Miss: Every code in `opts.Missing` returns an error for which `aircraft.IsNotFound` is true.
+//	Append: A record returned by `opts.NewRecord` can be found by its code once it has been appended.
+//	Concurrency: Concurrent calls to `Find` and `Append` are safe and appended records can be found afterwards.
+//	Cancellation: `Find` returns an error wrapping `context.Canceled` if its context has been cancelled.
+func RunConformance(t *testing.T, opts *ConformanceOptions) {
+
+	t.Helper()
+
+	if len(opts.Codes) == 0 {
+		t.Fatalf("Conformance options must define one or more codes")
+	}
+
+	t.Run("Find", func(t *testing.T) {
+		testFind(t, opts)
+	})
+
+	t.Run("Miss", func(t *testing.T) {
+		testMiss(t, opts)
+	})
+
+	t.Run("Append", func(t *testing.T) {
+
+		if opts.NewRecord == nil {
+			t.Skip("NewRecord is not defined")
+		}
+
+		testAppend(t, opts)
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		testConcurrency(t, opts)
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		testCancellation(t, opts)
+	})
+}
+
+func newLookup(t *testing.T, ctx context.Context, opts *ConformanceOptions) aircraft.Lookup {
+
+	t.Helper()
+
+	l, err := opts.NewLookup(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	return l
+}
+
+func testFind(t *testing.T, opts *ConformanceOptions) {
+
+	ctx := context.Background()
+	l := newLookup(t, ctx, opts)
+
+	for _, code := range opts.Codes {
+
+		results, err := l.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find '%s', %v", code, err)
+		}
+
+		if len(results) == 0 {
+			t.Fatalf("Expected one or more results for '%s'", code)
+		}
+
+		for i, r := range results {
+
+			if r == nil {
+				t.Fatalf("Result %d for '%s' is nil", i, code)
+			}
+		}
+	}
+}
+
+func testMiss(t *testing.T, opts *ConformanceOptions) {
+
+	ctx := context.Background()
+	l := newLookup(t, ctx, opts)
+
+	for _, code := range opts.Missing {
+
+		results, err := l.Find(ctx, code)
+
+		if !aircraft.IsNotFound(err) {
+			t.Fatalf("Expected not found error for '%s', got %v", code, err)
+		}
+
+		if len(results) != 0 {
+			t.Fatalf("Expected no results for '%s', got %d", code, len(results))
+		}
+	}
+}
+
+func testAppend(t *testing.T, opts *ConformanceOptions) {
+
+	ctx := context.Background()
+	l := newLookup(t, ctx, opts)
+
+	code, record := opts.NewRecord(0)
+
+	// Query the code first so that lookups which cache results are
+	// tested for returning stale misses after an append
+
+	_, err := l.Find(ctx, code)
+
+	if !aircraft.IsNotFound(err) {
+		t.Fatalf("Expected not found error for '%s' before append, got %v", code, err)
+	}
+
+	err = l.Append(ctx, record)
+
+	if err != nil {
+		t.Fatalf("Failed to append record for '%s', %v", code, err)
+	}
+
+	assertFound(t, ctx, l, code, record)
+}
+
+func testConcurrency(t *testing.T, opts *ConformanceOptions) {
+
+	ctx := context.Background()
+	l := newLookup(t, ctx, opts)
+
+	appended := make(map[string]interface{})
+	mu := new(sync.Mutex)
+
+	wg := new(sync.WaitGroup)
+
+	for w := 0; w < CONFORMANCE_WORKERS; w++ {
+
+		wg.Add(1)
+
+		go func(w int) {
+
+			defer wg.Done()
+
+			for i := 0; i < 10; i++ {
+
+				for _, code := range opts.Codes {
+
+					_, err := l.Find(ctx, code)
+
+					if err != nil {
+						t.Errorf("Failed to find '%s', %v", code, err)
+						return
+					}
+				}
+
+				if opts.NewRecord == nil || i != 0 {
+					continue
+				}
+
+				code, record := opts.NewRecord(1 + w)
+
+				err := l.Append(ctx, record)
+
+				if err != nil {
+					t.Errorf("Failed to append record for '%s', %v", code, err)
+					return
+				}
+
+				mu.Lock()
+				appended[code] = record
+				mu.Unlock()
+			}
+
+		}(w)
+	}
+
+	wg.Wait()
+
+	for code, record := range appended {
+		assertFound(t, ctx, l, code, record)
+	}
+}
+
+func testCancellation(t *testing.T, opts *ConformanceOptions) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	l := newLookup(t, ctx, opts)
+	cancel()
+
+	_, err := l.Find(ctx, opts.Codes[0])
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled error for '%s', got %v", opts.Codes[0], err)
+	}
+}
+
+// assertFound fails the test unless one of the results for `code` is equal to `record`. Results returned by an `aircraft.ChainLookup`
+// instance are unwrapped before being compared.
+func assertFound(t *testing.T, ctx context.Context, l aircraft.Lookup, code string, record interface{}) {
+
+	t.Helper()
+
+	results, err := l.Find(ctx, code)
+
+	if err != nil {
+		t.Fatalf("Failed to find '%s', %v", code, err)
+	}
+
+	for _, r := range results {
+
+		cr, ok := r.(*aircraft.ChainResult)
+
+		if ok {
+			r = cr.Record
+		}
+
+		if reflect.DeepEqual(r, record) {
+			return
+		}
+	}
+
+	t.Fatalf("Results for '%s' do not contain the appended record", code)
+}
@@ -0,0 +1,120 @@
+// package aircrafttest provides helpers for testing code that uses, or implements, the `aircraft.Lookup` interface: an in-memory
+// fake lookup, small fixture datasets and a conformance suite that every `aircraft.Lookup` implementation should pass.
+package aircrafttest
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"sync"
+)
+
+// FakeLookup is an in-memory `aircraft.Lookup` implementation. It is safe for concurrent use and records the number of times
+// its `Find` method has been called.
+type FakeLookup struct {
+	aircraft.Lookup
+	mu      *sync.RWMutex
+	codes   aircraft.CodesFunc
+	records map[string][]interface{}
+	finds   int
+}
+
+// NewFakeLookup will return a new `FakeLookup` instance containing `records`, each of which is indexed by the codes returned
+// by `codes`. If `codes` is nil then `DefaultCodes` is used.
+func NewFakeLookup(codes aircraft.CodesFunc, records ...interface{}) *FakeLookup {
+
+	if codes == nil {
+		codes = DefaultCodes
+	}
+
+	l := &FakeLookup{
+		mu:      new(sync.RWMutex),
+		codes:   codes,
+		records: make(map[string][]interface{}),
+	}
+
+	ctx := context.Background()
+
+	for _, r := range records {
+		l.Append(ctx, r)
+	}
+
+	return l
+}
+
+// DefaultCodes returns the codes for `*icao.Aircraft` and `*sfomuseum.Aircraft` records, as determined by their respective
+// `LookupCodes` functions. It returns nil for any other type of record.
+func DefaultCodes(r interface{}) []string {
+
+	switch a := r.(type) {
+	case *icao.Aircraft:
+		return icao.LookupCodes(a)
+	case *sfomuseum.Aircraft:
+		return sfomuseum.LookupCodes(a)
+	default:
+		return nil
+	}
+}
+
+// Find will return the list of records matching `code`.
+func (l *FakeLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.finds += 1
+
+	records, ok := l.records[code]
+
+	if !ok {
+		return nil, aircraft.NotFound(code)
+	}
+
+	results := make([]interface{}, len(records))
+	copy(results, records)
+
+	return results, nil
+}
+
+// Append will add `data` to the lookup. It returns an error if `data` is not indexed by any codes.
+func (l *FakeLookup) Append(ctx context.Context, data interface{}) error {
+
+	codes := l.codes(data)
+
+	if len(codes) == 0 {
+		return fmt.Errorf("Record %v (%T) has no codes", data, data)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, code := range codes {
+
+		records := l.records[code]
+
+		if len(records) > 0 && records[len(records)-1] == data {
+			continue
+		}
+
+		l.records[code] = append(records, data)
+	}
+
+	return nil
+}
+
+// Finds returns the number of times the `Find` method has been called.
+func (l *FakeLookup) Finds() int {
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.finds
+}
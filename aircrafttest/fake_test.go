@@ -0,0 +1,28 @@
+package aircrafttest
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"testing"
+)
+
+func TestFakeLookupConformance(t *testing.T) {
+
+	l := NewICAOFixturesLookup()
+
+	opts := &ConformanceOptions{
+		NewLookup: func(ctx context.Context) (aircraft.Lookup, error) {
+			return l, nil
+		},
+		Codes:   []string{"A320", "designator:B744", "manufacturer:BOEING"},
+		Missing: []string{"XXXX", "designator:BOEING"},
+		NewRecord: func(i int) (string, interface{}) {
+			code := fmt.Sprintf("ZF%02d", i)
+			return code, &icao.Aircraft{Designator: code, ManufacturerCode: "EXAMPLE"}
+		},
+	}
+
+	RunConformance(t, opts)
+}
@@ -0,0 +1,53 @@
+package aircrafttest
+
+import (
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+)
+
+// ICAOFixtures returns a small set of `icao.Aircraft` records, copied from the embedded data, suitable for use in tests. A new
+// list is returned on every call so callers are free to modify it.
+func ICAOFixtures() []*icao.Aircraft {
+
+	return []*icao.Aircraft{
+		&icao.Aircraft{ModelFullName: "A-320", Description: "L2J", WTC: "M", Designator: "A320", ManufacturerCode: "AIRBUS", AircraftDescription: "LandPlane", EngineCount: "2", EngineType: "Jet"},
+		&icao.Aircraft{ModelFullName: "A-320 Prestige", Description: "L2J", WTC: "M", Designator: "A320", ManufacturerCode: "AIRBUS", AircraftDescription: "LandPlane", EngineCount: "2", EngineType: "Jet"},
+		&icao.Aircraft{ModelFullName: "727-200", Description: "L3J", WTC: "M", Designator: "B722", ManufacturerCode: "BOEING", AircraftDescription: "LandPlane", EngineCount: "3", EngineType: "Jet"},
+		&icao.Aircraft{ModelFullName: "747-400", Description: "L4J", WTC: "H", Designator: "B744", ManufacturerCode: "BOEING", AircraftDescription: "LandPlane", EngineCount: "4", EngineType: "Jet"},
+	}
+}
+
+// SFOMuseumFixtures returns a small set of `sfomuseum.Aircraft` records, copied from the embedded data, suitable for use in tests.
+// A new list is returned on every call so callers are free to modify it.
+func SFOMuseumFixtures() []*sfomuseum.Aircraft {
+
+	return []*sfomuseum.Aircraft{
+		&sfomuseum.Aircraft{WOFID: 1159289391, Name: "Airbus A300-600R", SFOMuseumID: 17, ICAODesignator: "A306", WikidataID: "Q6437"},
+		&sfomuseum.Aircraft{WOFID: 1159289915, Name: "Boeing 747-400", SFOMuseumID: 250, ICAODesignator: "B744", WikidataID: "Q906937"},
+		&sfomuseum.Aircraft{WOFID: 1528104577, Name: "Boeing 737-MAX 9", SFOMuseumID: -1, ICAODesignator: "B39M", WikidataID: "Q139289"},
+	}
+}
+
+// NewICAOFixturesLookup returns a `FakeLookup` instance containing the records returned by `ICAOFixtures`.
+func NewICAOFixturesLookup() *FakeLookup {
+
+	records := make([]interface{}, 0)
+
+	for _, a := range ICAOFixtures() {
+		records = append(records, a)
+	}
+
+	return NewFakeLookup(DefaultCodes, records...)
+}
+
+// NewSFOMuseumFixturesLookup returns a `FakeLookup` instance containing the records returned by `SFOMuseumFixtures`.
+func NewSFOMuseumFixturesLookup() *FakeLookup {
+
+	records := make([]interface{}, 0)
+
+	for _, a := range SFOMuseumFixtures() {
+		records = append(records, a)
+	}
+
+	return NewFakeLookup(DefaultCodes, records...)
+}
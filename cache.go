@@ -0,0 +1,289 @@
+package aircraft
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The default maximum number of entries for a `CacheLookup` instance.
+const CACHE_DEFAULT_SIZE int = 1024
+
+// CacheLookup is an `aircraft.Lookup` implementation that caches the results of another `aircraft.Lookup` instance in a bounded,
+// least-recently-used cache. Both positive and negative (not found) results are cached. Other errors are never cached.
+type CacheLookup struct {
+	Lookup
+	lookup     Lookup
+	mu         *sync.Mutex
+	size       int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	lru        *list.List
+	generation int64
+	hits       int64
+	misses     int64
+	evictions  int64
+}
+
+// CacheStats contains statistics about a `CacheLookup` instance.
+type CacheStats struct {
+	// The number of calls to `Find` answered from the cache.
+	Hits int64
+	// The number of calls to `Find` passed through to the underlying lookup.
+	Misses int64
+	// The number of entries removed from the cache because it was full.
+	Evictions int64
+	// The number of entries currently in the cache.
+	Size int
+}
+
+type cacheEntry struct {
+	code    string
+	results []interface{}
+	err     error
+	expires time.Time
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterLookup(ctx, "cache", NewCacheLookup)
+}
+
+// NewCacheLookup will return an `aircraft.Lookup` instance that caches the results of another lookup. `uri` takes the form of:
+//	`cache://?lookup={LOOKUP_URI}&size={SIZE}&ttl={TTL}`
+// Where `{LOOKUP_URI}` is a valid (and URL-escaped, if it has its own query parameters) `aircraft.Lookup` URI, `{SIZE}` is the maximum
+// number of entries to cache (default 1024) and `{TTL}` is an optional duration (for example "10m" or a number of seconds) after which
+// entries expire. If `{TTL}` is empty entries never expire.
+func NewCacheLookup(ctx context.Context, uri string) (Lookup, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	lookup_uri := q.Get("lookup")
+
+	if lookup_uri == "" {
+		return nil, fmt.Errorf("Missing ?lookup= parameter")
+	}
+
+	size := CACHE_DEFAULT_SIZE
+
+	if q.Get("size") != "" {
+
+		sz, err := strconv.Atoi(q.Get("size"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?size= parameter, %w", err)
+		}
+
+		size = sz
+	}
+
+	var ttl time.Duration
+
+	if q.Get("ttl") != "" {
+
+		ttl, err = parseTTL(q.Get("ttl"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?ttl= parameter, %w", err)
+		}
+	}
+
+	lookup, err := NewLookup(ctx, lookup_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create lookup for %s, %w", lookup_uri, err)
+	}
+
+	return NewCacheLookupWithLookup(ctx, lookup, size, ttl)
+}
+
+// NewCacheLookupWithLookup will return a `CacheLookup` instance that caches up to `size` results from `lookup`. If `ttl` is greater
+// than zero entries will expire after that duration.
+func NewCacheLookupWithLookup(ctx context.Context, lookup Lookup, size int, ttl time.Duration) (*CacheLookup, error) {
+
+	if size < 1 {
+		return nil, fmt.Errorf("Invalid cache size %d", size)
+	}
+
+	l := &CacheLookup{
+		lookup:  lookup,
+		mu:      new(sync.Mutex),
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	return l, nil
+}
+
+// Find will return the (possibly cached) results of calling `Find` on the underlying lookup.
+func (l *CacheLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+
+	el, ok := l.entries[code]
+
+	if ok {
+
+		e := el.Value.(*cacheEntry)
+
+		if l.ttl > 0 && time.Now().After(e.expires) {
+			l.lru.Remove(el)
+			delete(l.entries, code)
+		} else {
+
+			l.lru.MoveToFront(el)
+			l.hits += 1
+			l.mu.Unlock()
+
+			if e.err != nil {
+				return nil, e.err
+			}
+
+			results := make([]interface{}, len(e.results))
+			copy(results, e.results)
+
+			return results, nil
+		}
+	}
+
+	l.misses += 1
+	generation := l.generation
+
+	l.mu.Unlock()
+
+	// Don't hold the lock while querying the underlying lookup since that
+	// might be slow and would serialize every cache miss
+
+	results, err := l.lookup.Find(ctx, code)
+
+	if err != nil && !IsNotFound(err) {
+		return nil, err
+	}
+
+	e := &cacheEntry{
+		code: code,
+		err:  err,
+	}
+
+	if err == nil {
+		e.results = make([]interface{}, len(results))
+		copy(e.results, results)
+	}
+
+	if l.ttl > 0 {
+		e.expires = time.Now().Add(l.ttl)
+	}
+
+	l.store(generation, e)
+
+	return results, err
+}
+
+// Append will append `data` to the underlying lookup and, if successful, purge the cache.
+func (l *CacheLookup) Append(ctx context.Context, data interface{}) error {
+
+	err := l.lookup.Append(ctx, data)
+
+	if err != nil {
+		return err
+	}
+
+	l.Purge()
+	return nil
+}
+
+// Purge removes all entries from the cache.
+func (l *CacheLookup) Purge() {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = make(map[string]*list.Element)
+	l.lru.Init()
+
+	// Increment the generation so that any lookups which started before
+	// the cache was purged don't (re)populate it with stale results
+
+	l.generation += 1
+}
+
+// Stats returns hit, miss and size statistics for the cache.
+func (l *CacheLookup) Stats() CacheStats {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := CacheStats{
+		Hits:      l.hits,
+		Misses:    l.misses,
+		Evictions: l.evictions,
+		Size:      l.lru.Len(),
+	}
+
+	return s
+}
+
+func (l *CacheLookup) store(generation int64, e *cacheEntry) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if generation != l.generation {
+		return
+	}
+
+	el, ok := l.entries[e.code]
+
+	if ok {
+		el.Value = e
+		l.lru.MoveToFront(el)
+		return
+	}
+
+	l.entries[e.code] = l.lru.PushFront(e)
+
+	for l.lru.Len() > l.size {
+
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+
+		delete(l.entries, oldest.Value.(*cacheEntry).code)
+		l.evictions += 1
+	}
+}
+
+// parseTTL parses `str` as a `time.Duration` string or, failing that, as a number of seconds.
+func parseTTL(str string) (time.Duration, error) {
+
+	d, err := time.ParseDuration(str)
+
+	if err == nil {
+		return d, nil
+	}
+
+	secs, err := strconv.Atoi(str)
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid duration '%s'", str)
+	}
+
+	return time.Duration(secs) * time.Second, nil
+}
@@ -0,0 +1,115 @@
+package aircraft_test
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"testing"
+	"time"
+)
+
+func TestCacheLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	source := aircrafttest.NewFakeLookup(nil,
+		&icao.Aircraft{Designator: "A", ModelFullName: "a"},
+		&icao.Aircraft{Designator: "B", ModelFullName: "b"},
+		&icao.Aircraft{Designator: "C", ModelFullName: "c"},
+	)
+
+	l, err := aircraft.NewCacheLookupWithLookup(ctx, source, 2, 0)
+
+	if err != nil {
+		t.Fatalf("Failed to create cache lookup, %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+
+		results, err := l.Find(ctx, "A")
+
+		if err != nil || len(results) != 1 {
+			t.Fatalf("Failed to find A, %v", err)
+		}
+	}
+
+	if source.Finds() != 1 {
+		t.Fatalf("Expected 1 call to underlying lookup, got %d", source.Finds())
+	}
+
+	for i := 0; i < 2; i++ {
+
+		_, err := l.Find(ctx, "X")
+
+		if !aircraft.IsNotFound(err) {
+			t.Fatalf("Expected not found error, got %v", err)
+		}
+	}
+
+	if source.Finds() != 2 {
+		t.Fatalf("Expected negative result to be cached, got %d calls", source.Finds())
+	}
+
+	// Cache is now full (A, X) so finding B should evict A
+
+	l.Find(ctx, "B")
+	l.Find(ctx, "A")
+
+	stats := l.Stats()
+
+	if stats.Hits != 3 || stats.Misses != 4 || stats.Evictions != 2 || stats.Size != 2 {
+		t.Fatalf("Unexpected stats, %v", stats)
+	}
+
+	err = l.Append(ctx, &icao.Aircraft{Designator: "X", ModelFullName: "x"})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	if l.Stats().Size != 0 {
+		t.Fatalf("Expected append to purge cache")
+	}
+
+	results, err := l.Find(ctx, "X")
+
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected to find appended record, %v", err)
+	}
+}
+
+func TestCacheLookupTTL(t *testing.T) {
+
+	ctx := context.Background()
+
+	source := aircrafttest.NewFakeLookup(nil, &icao.Aircraft{Designator: "A", ModelFullName: "a"})
+
+	l, err := aircraft.NewCacheLookupWithLookup(ctx, source, 10, time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("Failed to create cache lookup, %v", err)
+	}
+
+	l.Find(ctx, "A")
+	time.Sleep(5 * time.Millisecond)
+	l.Find(ctx, "A")
+
+	if source.Finds() != 2 {
+		t.Fatalf("Expected expired entry to be refetched, got %d calls", source.Finds())
+	}
+}
+
+func TestCacheLookupURI(t *testing.T) {
+
+	ctx := context.Background()
+
+	for _, uri := range []string{"cache://", "cache://?lookup=cache://&size=0", "cache://?lookup=example://&ttl=soon"} {
+
+		_, err := aircraft.NewLookup(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", uri)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package aircraft
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// CHAIN_MODE_FIRST causes a `ChainLookup` to return the results of the first lookup that finds a code.
+const CHAIN_MODE_FIRST string = "first"
+
+// CHAIN_MODE_ALL causes a `ChainLookup` to return the (merged) results of every lookup that finds a code.
+const CHAIN_MODE_ALL string = "all"
+
+// ChainLookup is an `aircraft.Lookup` implementation that queries a list of other `aircraft.Lookup` instances in order.
+type ChainLookup struct {
+	Lookup
+	sources  []string
+	lookups  []Lookup
+	mode     string
+	writable int
+}
+
+// ChainResult wraps a record returned by a `ChainLookup` instance with the source it came from.
+type ChainResult struct {
+	// The source (for example the lookup URI) of the lookup that returned `Record`.
+	Source string
+	// The record returned by the lookup.
+	Record interface{}
+}
+
+func (r *ChainResult) String() string {
+	return fmt.Sprintf("%v (%s)", r.Record, r.Source)
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterLookup(ctx, "chain", NewChainLookup)
+}
+
+// NewChainLookup will return an `aircraft.Lookup` instance that queries multiple lookups in order. `uri` takes the form of:
+//	`chain://?lookup={LOOKUP_URI}&lookup={LOOKUP_URI}&mode={MODE}&writable={INDEX}`
+// Where each `{LOOKUP_URI}` is a valid (and URL-escaped, if it has its own query parameters) `aircraft.Lookup` URI, `{MODE}` is either
+// "first" (default) to return the results of the first lookup to find a code or "all" to return the results of every lookup and
+// `{INDEX}` is the (zero-based) position of the lookup that calls to `Append` are routed to (default 0). If `{INDEX}` is -1 the chain
+// is read-only.
+func NewChainLookup(ctx context.Context, uri string) (Lookup, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	q := u.Query()
+
+	lookup_uris := q["lookup"]
+
+	if len(lookup_uris) == 0 {
+		return nil, fmt.Errorf("Missing ?lookup= parameter")
+	}
+
+	mode := CHAIN_MODE_FIRST
+
+	if q.Get("mode") != "" {
+		mode = q.Get("mode")
+	}
+
+	writable := 0
+
+	if q.Get("writable") != "" {
+
+		w, err := strconv.Atoi(q.Get("writable"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?writable= parameter, %w", err)
+		}
+
+		writable = w
+	}
+
+	lookups := make([]Lookup, len(lookup_uris))
+
+	for i, lookup_uri := range lookup_uris {
+
+		l, err := NewLookup(ctx, lookup_uri)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create lookup for %s, %w", lookup_uri, err)
+		}
+
+		lookups[i] = l
+	}
+
+	return NewChainLookupWithLookups(ctx, lookup_uris, lookups, mode, writable)
+}
+
+// NewChainLookupWithLookups will return a `ChainLookup` instance for `lookups`. `sources` are the labels used to annotate the results of
+// the corresponding lookups, `mode` is one of `CHAIN_MODE_FIRST` or `CHAIN_MODE_ALL` and `writable` is the index of the lookup that
+// calls to `Append` are routed to or -1 if the chain is read-only.
+func NewChainLookupWithLookups(ctx context.Context, sources []string, lookups []Lookup, mode string, writable int) (*ChainLookup, error) {
+
+	if len(sources) != len(lookups) {
+		return nil, fmt.Errorf("Number of sources (%d) does not match number of lookups (%d)", len(sources), len(lookups))
+	}
+
+	switch mode {
+	case CHAIN_MODE_FIRST, CHAIN_MODE_ALL:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid mode '%s'", mode)
+	}
+
+	if writable < -1 || writable >= len(lookups) {
+		return nil, fmt.Errorf("Invalid writable lookup %d", writable)
+	}
+
+	l := &ChainLookup{
+		sources:  sources,
+		lookups:  lookups,
+		mode:     mode,
+		writable: writable,
+	}
+
+	return l, nil
+}
+
+// Find will query each lookup in the chain, in order, for `code`. Each result is returned as a `*ChainResult` instance identifying the
+// lookup it came from. Lookups that do not find `code` are skipped; any other error is returned immediately.
+func (l *ChainLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	results := make([]interface{}, 0)
+
+	for i, lookup := range l.lookups {
+
+		rsp, err := lookup.Find(ctx, code)
+
+		if err != nil {
+
+			if IsNotFound(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("Failed to find '%s' in %s, %w", code, l.sources[i], err)
+		}
+
+		for _, r := range rsp {
+
+			cr := &ChainResult{
+				Source: l.sources[i],
+				Record: r,
+			}
+
+			results = append(results, cr)
+		}
+
+		if len(rsp) > 0 && l.mode == CHAIN_MODE_FIRST {
+			break
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, NotFound(code)
+	}
+
+	return results, nil
+}
+
+// Append will append `data` to the chain's writable lookup. If `data` is a `*ChainResult` instance its record is appended.
+func (l *ChainLookup) Append(ctx context.Context, data interface{}) error {
+
+	if l.writable == -1 {
+		return fmt.Errorf("Chain does not have a writable lookup")
+	}
+
+	cr, ok := data.(*ChainResult)
+
+	if ok {
+		data = cr.Record
+	}
+
+	return l.lookups[l.writable].Append(ctx, data)
+}
@@ -0,0 +1,99 @@
+package aircraft_test
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"testing"
+)
+
+func TestChainLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	local := aircrafttest.NewFakeLookup(nil, &icao.Aircraft{Designator: "A", ModelFullName: "local a"})
+
+	embedded := aircrafttest.NewFakeLookup(nil,
+		&icao.Aircraft{Designator: "A", ModelFullName: "embedded a"},
+		&icao.Aircraft{Designator: "B", ModelFullName: "embedded b"},
+	)
+
+	sources := []string{"local", "embedded"}
+	lookups := []aircraft.Lookup{local, embedded}
+
+	first, err := aircraft.NewChainLookupWithLookups(ctx, sources, lookups, aircraft.CHAIN_MODE_FIRST, 0)
+
+	if err != nil {
+		t.Fatalf("Failed to create chain lookup, %v", err)
+	}
+
+	all, err := aircraft.NewChainLookupWithLookups(ctx, sources, lookups, aircraft.CHAIN_MODE_ALL, -1)
+
+	if err != nil {
+		t.Fatalf("Failed to create chain lookup, %v", err)
+	}
+
+	tests := []struct {
+		lookup  aircraft.Lookup
+		code    string
+		sources []string
+	}{
+		{first, "A", []string{"local"}},
+		{first, "B", []string{"embedded"}},
+		{all, "A", []string{"local", "embedded"}},
+		{all, "B", []string{"embedded"}},
+	}
+
+	for _, test := range tests {
+
+		results, err := test.lookup.Find(ctx, test.code)
+
+		if err != nil {
+			t.Fatalf("Failed to find '%s', %v", test.code, err)
+		}
+
+		if len(results) != len(test.sources) {
+			t.Fatalf("Expected %d results for '%s', got %d", len(test.sources), test.code, len(results))
+		}
+
+		for i, r := range results {
+
+			cr := r.(*aircraft.ChainResult)
+
+			if cr.Source != test.sources[i] {
+				t.Fatalf("Expected result %d for '%s' to come from %s, got %s", i, test.code, test.sources[i], cr.Source)
+			}
+		}
+	}
+
+	_, err = first.Find(ctx, "C")
+
+	if !aircraft.IsNotFound(err) {
+		t.Fatalf("Expected not found error, got %v", err)
+	}
+
+	err = first.Append(ctx, &icao.Aircraft{Designator: "C", ModelFullName: "local c"})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	_, err = local.Find(ctx, "C")
+
+	if err != nil {
+		t.Fatalf("Expected record to be appended to writable lookup, %v", err)
+	}
+
+	err = all.Append(ctx, &icao.Aircraft{Designator: "D"})
+
+	if err == nil {
+		t.Fatalf("Expected append to read-only chain to fail")
+	}
+
+	_, err = aircraft.NewChainLookupWithLookups(ctx, sources, lookups, "example", 0)
+
+	if err == nil {
+		t.Fatalf("Expected invalid mode to fail")
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"flag"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
 	"io"
 	"log"
 	"net/http"
@@ -17,6 +21,8 @@ func main() {
 
 	target := flag.String("target", "data/icao.json", "The path to write ICAO aircraft data.")
 	stdout := flag.Bool("stdout", false, "Emit ICAO aircraft data to SDOUT.")
+	compressed_target := flag.String("compressed-target", "data/icao.json.gz", "The path to write gzip-compressed ICAO aircraft data, which is what gets embedded in the data package. If empty no compressed data is written.")
+	index_target := flag.String("index-target", "data/icao.idx", "The path to write a precompiled binary index of ICAO aircraft data. If empty no index is written.")
 
 	flag.Parse()
 
@@ -30,10 +36,37 @@ func main() {
 
 	writers = append(writers, fh)
 
+	var gz *gzip.Writer
+
+	if *compressed_target != "" {
+
+		gz_fh, err := os.Create(*compressed_target)
+
+		if err != nil {
+			log.Fatalf("Failed to open '%s', %v", *compressed_target, err)
+		}
+
+		defer gz_fh.Close()
+
+		gz, err = gzip.NewWriterLevel(gz_fh, gzip.BestCompression)
+
+		if err != nil {
+			log.Fatalf("Failed to create gzip writer, %v", err)
+		}
+
+		writers = append(writers, gz)
+	}
+
 	if *stdout {
 		writers = append(writers, os.Stdout)
 	}
 
+	var buf bytes.Buffer
+
+	if *index_target != "" {
+		writers = append(writers, &buf)
+	}
+
 	wr := io.MultiWriter(writers...)
 
 	data := strings.NewReader("")
@@ -64,4 +97,43 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to write data, %v", err)
 	}
+
+	if gz != nil {
+
+		err = gz.Close()
+
+		if err != nil {
+			log.Fatalf("Failed to close compressed data, %v", err)
+		}
+	}
+
+	if *index_target != "" {
+
+		var aircraft_list []*icao.Aircraft
+
+		dec := json.NewDecoder(&buf)
+		err = dec.Decode(&aircraft_list)
+
+		if err != nil {
+			log.Fatalf("Failed to decode data, %v", err)
+		}
+
+		index_fh, err := os.Create(*index_target)
+
+		if err != nil {
+			log.Fatalf("Failed to open '%s', %v", *index_target, err)
+		}
+
+		err = icao.WriteIndex(index_fh, aircraft_list)
+
+		if err != nil {
+			log.Fatalf("Failed to write index, %v", err)
+		}
+
+		err = index_fh.Close()
+
+		if err != nil {
+			log.Fatalf("Failed to close '%s', %v", *index_target, err)
+		}
+	}
 }
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"github.com/aaronland/go-json-query"
 	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 )
 
 func main() {
@@ -17,14 +23,108 @@ func main() {
 
 	target := flag.String("target", "data/sfomuseum.json", "The path to write SFO Museum aircraft data.")
 	stdout := flag.Bool("stdout", false, "Emit SFO Museum aircraft data to SDOUT.")
+	compressed_target := flag.String("compressed-target", "data/sfomuseum.json.gz", "The path to write gzip-compressed SFO Museum aircraft data, which is what gets embedded in the data package. If empty no compressed data is written.")
+	index_target := flag.String("index-target", "data/sfomuseum.idx", "The path to write a precompiled binary index of SFO Museum aircraft data. If empty no index is written.")
+
+	var properties sfomuseum.PropertyFlags
+	flag.Var(&properties, "property", "One or more {PATH} or {PATH}={TYPE} parameters for additional GeoJSON properties to extract in to each record's extras. Paths are relative to the feature's properties. Valid types are: string, int, float, bool, json. If no type is specified then string is assumed.")
+
+	var includes query.QueryFlags
+	flag.Var(&includes, "include", "One or more {PATH}={REGEXP} parameters that a feature must match in order to be included in the compiled data.")
+
+	var excludes query.QueryFlags
+	flag.Var(&excludes, "exclude", "One or more {PATH}={REGEXP} parameters that, if matched, will cause a feature to be excluded from the compiled data.")
+
+	valid_modes := strings.Join([]string{query.QUERYSET_MODE_ALL, query.QUERYSET_MODE_ANY}, ", ")
+
+	include_mode := flag.String("include-mode", sfomuseum.DEFAULT_INCLUDE_MODE, fmt.Sprintf("Specify how -include queries should be evaluated. Valid modes are: %s", valid_modes))
+	exclude_mode := flag.String("exclude-mode", sfomuseum.DEFAULT_EXCLUDE_MODE, fmt.Sprintf("Specify how -exclude queries should be evaluated. Valid modes are: %s", valid_modes))
+
+	lenient := flag.Bool("lenient", false, "Skip features that fail to compile rather than aborting. Failures are included in the compile report.")
+	max_failures := flag.Int("max-failures", 0, "The maximum number of features that may fail to compile, when -lenient is true, before exiting with a non-zero status. Compiled data is written either way. If -1 there is no maximum.")
+	print_report := flag.Bool("report", false, "Print a report of the number of processed, skipped and failed features, and the reason for each failure, to STDERR.")
+
+	state_path := flag.String("state", "", "The path to a compile state file. If present, and -previous data exists, only features that have changed since the previous compilation are reprocessed. The state file is updated after each compilation. If empty data is always compiled in full.")
+	previous_path := flag.String("previous", "", "The path to previously compiled SFO Museum aircraft data to use with -state. If empty the value of -target is used.")
+
+	verbose := flag.Bool("verbose", false, "Print the progress of the compilation (features seen, records emitted and failures) to STDERR.")
 
 	flag.Parse()
 
-	ctx := context.Background()
+	for _, mode := range []string{*include_mode, *exclude_mode} {
+
+		switch mode {
+		case query.QUERYSET_MODE_ALL, query.QUERYSET_MODE_ANY:
+			// pass
+		default:
+			log.Fatalf("Invalid query mode '%s'", mode)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	compile_opts := &sfomuseum.CompileOptions{
+		Properties: properties,
+		Lenient:    *lenient,
+	}
+
+	if len(includes) > 0 {
+
+		compile_opts.Include = &query.QuerySet{
+			Queries: includes,
+			Mode:    *include_mode,
+		}
+	}
+
+	if len(excludes) > 0 {
+
+		compile_opts.Exclude = &query.QuerySet{
+			Queries: excludes,
+			Mode:    *exclude_mode,
+		}
+	}
+
+	if *state_path != "" {
+
+		if *previous_path == "" {
+			*previous_path = *target
+		}
+
+		previous_state, previous, err := readPrevious(ctx, *state_path, *previous_path)
+
+		if err != nil {
+			log.Fatalf("Failed to read previous compilation, %v", err)
+		}
+
+		compile_opts.PreviousState = previous_state
+		compile_opts.Previous = previous
+	}
+
+	if *verbose {
+		compile_opts.Progress = newProgressFunc(time.Second)
+	}
+
+	lookup, report, err := sfomuseum.CompileAircraftDataWithReport(ctx, compile_opts, *iterator_uri, *iterator_source)
+
+	if *verbose && report != nil {
+		log.Printf("Compile progress: %s\n", report.Progress())
+	}
+
+	if report != nil && (*print_report || (*lenient && report.Failed > 0)) {
+		printReport(report)
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	// Only open targets once data has been compiled successfully so that
+	// a failed or cancelled compilation does not truncate existing data
 
 	writers := make([]io.Writer, 0)
 
-	fh, err := os.OpenFile(*target, os.O_RDWR|os.O_CREATE, 0644)
+	fh, err := os.OpenFile(*target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 
 	if err != nil {
 		log.Fatalf("Failed to open '%s', %v", *target, err)
@@ -32,22 +132,173 @@ func main() {
 
 	writers = append(writers, fh)
 
+	var gz *gzip.Writer
+
+	if *compressed_target != "" {
+
+		gz_fh, err := os.Create(*compressed_target)
+
+		if err != nil {
+			log.Fatalf("Failed to open '%s', %v", *compressed_target, err)
+		}
+
+		defer gz_fh.Close()
+
+		gz, err = gzip.NewWriterLevel(gz_fh, gzip.BestCompression)
+
+		if err != nil {
+			log.Fatalf("Failed to create gzip writer, %v", err)
+		}
+
+		writers = append(writers, gz)
+	}
+
 	if *stdout {
 		writers = append(writers, os.Stdout)
 	}
 
 	wr := io.MultiWriter(writers...)
 
-	lookup, err := sfomuseum.CompileAircraftData(ctx, *iterator_uri, *iterator_source)
+	enc := json.NewEncoder(wr)
+	err = enc.Encode(lookup)
 
 	if err != nil {
-		log.Fatalf("Failed to compile aircraft data, %v", err)
+		log.Fatalf("Failed to marshal results, %v", err)
 	}
 
-	enc := json.NewEncoder(wr)
-	err = enc.Encode(lookup)
+	if gz != nil {
+
+		err = gz.Close()
+
+		if err != nil {
+			log.Fatalf("Failed to close compressed data, %v", err)
+		}
+	}
+
+	if *state_path != "" {
+
+		err := writeState(*state_path, report.State)
+
+		if err != nil {
+			log.Fatalf("Failed to write compile state, %v", err)
+		}
+	}
+
+	if *index_target != "" {
+
+		index_fh, err := os.Create(*index_target)
+
+		if err != nil {
+			log.Fatalf("Failed to open '%s', %v", *index_target, err)
+		}
+
+		err = sfomuseum.WriteIndex(index_fh, lookup)
+
+		if err != nil {
+			log.Fatalf("Failed to write index, %v", err)
+		}
+
+		err = index_fh.Close()
+
+		if err != nil {
+			log.Fatalf("Failed to close '%s', %v", *index_target, err)
+		}
+	}
+
+	// Too many failures are reported once the (lenient) data has been written so that it can still be inspected
+
+	if *max_failures > -1 && report.Failed > *max_failures {
+		log.Fatalf("Failed to compile aircraft data, %d features failed to compile which exceeds the maximum of %d", report.Failed, *max_failures)
+	}
+}
+
+// printReport writes a summary of `report`, and the reason for each failure, to STDERR.
+func printReport(report *sfomuseum.CompileReport) {
+
+	log.Printf("Compile report: %s\n", report)
+
+	for _, f := range report.Failures {
+		log.Printf("Failed to compile %s: %v\n", f.Path, f.Err)
+	}
+}
+
+// readPrevious returns the compile state stored in `state_path` and the previously compiled data stored in `previous_path`. If either
+// file does not exist then nil values are returned.
+func readPrevious(ctx context.Context, state_path string, previous_path string) (*sfomuseum.CompileState, []*sfomuseum.Aircraft, error) {
+
+	state_fh, err := os.Open(state_path)
+
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
 
 	if err != nil {
-		log.Fatalf("Failed to marshal results, %v", err)
+		return nil, nil, err
+	}
+
+	defer state_fh.Close()
+
+	previous_fh, err := os.Open(previous_path)
+
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer previous_fh.Close()
+
+	state, err := sfomuseum.ReadCompileState(state_fh)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previous, err := sfomuseum.ReadAircraftData(ctx, previous_fh)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return state, previous, nil
+}
+
+// writeState writes `state` to `path`.
+func writeState(path string, state *sfomuseum.CompileState) error {
+
+	fh, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = state.Write(fh)
+
+	if err != nil {
+		fh.Close()
+		return err
+	}
+
+	return fh.Close()
+}
+
+// newProgressFunc returns a function, suitable for use as `sfomuseum.CompileOptions.Progress`, that writes the progress of a compilation
+// to STDERR at most once every `interval`.
+func newProgressFunc(interval time.Duration) func(*sfomuseum.CompileProgress) {
+
+	var last time.Time
+
+	return func(p *sfomuseum.CompileProgress) {
+
+		now := time.Now()
+
+		if now.Sub(last) < interval {
+			return
+		}
+
+		last = now
+		log.Printf("Compile progress: %s\n", p)
 	}
 }
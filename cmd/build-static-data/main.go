@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+)
+
+func main() {
+
+	icao_source := flag.String("icao-source", "", "The path to ICAO aircraft data to generate Go source from. If empty the precompiled (embedded) data is used.")
+	sfomuseum_source := flag.String("sfomuseum-source", "", "The path to SFO Museum aircraft data to generate Go source from. If empty the precompiled (embedded) data is used.")
+
+	icao_target := flag.String("icao-target", "static/icao_data.go", "The path to write generated ICAO aircraft Go source. If empty no source is generated.")
+	sfomuseum_target := flag.String("sfomuseum-target", "static/sfomuseum_data.go", "The path to write generated SFO Museum aircraft Go source. If empty no source is generated.")
+
+	package_name := flag.String("package", "static", "The name of the package for generated Go source.")
+
+	flag.Parse()
+
+	ctx := context.Background()
+
+	if *icao_target != "" {
+
+		var aircraft_list []*icao.Aircraft
+		var err error
+
+		if *icao_source != "" {
+			err = decodeFile(*icao_source, &aircraft_list)
+		} else {
+			aircraft_list, err = icao.LoadEmbeddedData(ctx)
+		}
+
+		if err != nil {
+			log.Fatalf("Failed to load ICAO data, %v", err)
+		}
+
+		records := make([]interface{}, len(aircraft_list))
+		keys := make(map[string][]int)
+
+		for i, a := range aircraft_list {
+			records[i] = *a
+			appendKeys(keys, i, icao.LookupCodes(a))
+		}
+
+		err = writeSource(*icao_target, *package_name, "icao", records, keys)
+
+		if err != nil {
+			log.Fatalf("Failed to write ICAO source, %v", err)
+		}
+	}
+
+	if *sfomuseum_target != "" {
+
+		var aircraft_list []*sfomuseum.Aircraft
+		var err error
+
+		if *sfomuseum_source != "" {
+			err = decodeFile(*sfomuseum_source, &aircraft_list)
+		} else {
+			aircraft_list, err = sfomuseum.LoadEmbeddedData(ctx)
+		}
+
+		if err != nil {
+			log.Fatalf("Failed to load SFO Museum data, %v", err)
+		}
+
+		records := make([]interface{}, len(aircraft_list))
+		keys := make(map[string][]int)
+
+		for i, a := range aircraft_list {
+			records[i] = *a
+			appendKeys(keys, i, sfomuseum.LookupCodes(a))
+		}
+
+		err = writeSource(*sfomuseum_target, *package_name, "sfomuseum", records, keys)
+
+		if err != nil {
+			log.Fatalf("Failed to write SFO Museum source, %v", err)
+		}
+	}
+}
+
+func decodeFile(path string, target interface{}) error {
+
+	fh, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer fh.Close()
+
+	dec := json.NewDecoder(fh)
+	return dec.Decode(target)
+}
+
+func appendKeys(keys map[string][]int, i int, codes []string) {
+
+	for _, code := range codes {
+
+		offsets := keys[code]
+
+		if len(offsets) > 0 && offsets[len(offsets)-1] == i {
+			continue
+		}
+
+		keys[code] = append(offsets, i)
+	}
+}
+
+// writeSource writes a Go source file to `path` declaring `{prefix}_aircraft`, a slice of `records`, and `{prefix}_keys`, a map
+// of lookup codes to offsets in that slice. See `renderSource` for details.
+func writeSource(path string, package_name string, prefix string, records []interface{}, keys map[string][]int) error {
+
+	src, err := renderSource(package_name, prefix, records, keys)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, src, 0644)
+}
+
+// renderSource returns Go source declaring `{prefix}_aircraft`, a slice of `records`, and `{prefix}_keys`, a map of lookup codes to
+// offsets in that slice. Records are rendered as composite literals (see `writeValue`) and the packages of any named types they
+// contain are imported.
+func renderSource(package_name string, prefix string, records []interface{}, keys map[string][]int) ([]byte, error) {
+
+	var body bytes.Buffer
+	imports := make(map[string]bool)
+
+	fmt.Fprintf(&body, "var %s_aircraft = []%s.Aircraft{\n", prefix, prefix)
+
+	for i, r := range records {
+
+		body.WriteString("\t")
+
+		err := writeValue(&body, reflect.ValueOf(r), imports)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to render record %d, %w", i, err)
+		}
+
+		body.WriteString(",\n")
+	}
+
+	fmt.Fprintf(&body, "}\n\n")
+
+	sorted := make([]string, 0, len(keys))
+
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+
+	sort.Strings(sorted)
+
+	fmt.Fprintf(&body, "var %s_keys = map[string][]int{\n", prefix)
+
+	for _, k := range sorted {
+		fmt.Fprintf(&body, "\t%q: %#v,\n", k, keys[k])
+	}
+
+	fmt.Fprintf(&body, "}\n")
+
+	import_paths := make([]string, 0, len(imports))
+
+	for path := range imports {
+		import_paths = append(import_paths, path)
+	}
+
+	sort.Strings(import_paths)
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by build-static-data. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", package_name)
+	fmt.Fprintf(&buf, "import (\n")
+
+	for _, path := range import_paths {
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+
+	fmt.Fprintf(&buf, ")\n\n")
+
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to format source, %w", err)
+	}
+
+	return src, nil
+}
+
+var raw_message_type = reflect.TypeOf(json.RawMessage(nil))
+
+// writeValue writes `v` to `buf` as a Go expression and records the package of each named type it contains in `imports`. This is
+// the same as the Go-syntax representation (%#v) except that values stored in interfaces (for example `sfomuseum.Aircraft.Extras`)
+// are written with an explicit conversion, so that a float64 value of 1 is still a float64 and not an int once it is compiled. An
+// error is returned for values which can not be represented as a literal, like channels or functions.
+func writeValue(buf *bytes.Buffer, v reflect.Value, imports map[string]bool) error {
+
+	t := v.Type()
+
+	// json.RawMessage is written by name since, depending on the version of Go, it may be an alias for a type in another package
+
+	if t == raw_message_type {
+		imports["encoding/json"] = true
+		fmt.Fprintf(buf, "json.RawMessage(%q)", v.Bytes())
+		return nil
+	}
+
+	if t.PkgPath() != "" {
+		imports[t.PkgPath()] = true
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+
+		if t.PkgPath() != "" {
+			fmt.Fprintf(buf, "%s(%#v)", t, v.Interface())
+		} else {
+			fmt.Fprintf(buf, "%#v", v.Interface())
+		}
+
+		return nil
+
+	case reflect.Interface:
+
+		if v.IsNil() {
+			buf.WriteString("nil")
+			return nil
+		}
+
+		e := v.Elem()
+
+		switch e.Kind() {
+		case reflect.Bool, reflect.String:
+			return writeValue(buf, e, imports)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+
+			if e.Type().PkgPath() != "" {
+				return writeValue(buf, e, imports)
+			}
+
+			fmt.Fprintf(buf, "%s(%#v)", e.Type(), e.Interface())
+			return nil
+		default:
+			return writeValue(buf, e, imports)
+		}
+
+	case reflect.Struct:
+
+		fmt.Fprintf(buf, "%s{", t)
+
+		for i := 0; i < v.NumField(); i++ {
+
+			f := t.Field(i)
+
+			if f.PkgPath != "" {
+				return fmt.Errorf("Unable to render unexported field %s.%s", t, f.Name)
+			}
+
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			fmt.Fprintf(buf, "%s: ", f.Name)
+
+			err := writeValue(buf, v.Field(i), imports)
+
+			if err != nil {
+				return fmt.Errorf("Failed to render %s.%s, %w", t, f.Name, err)
+			}
+		}
+
+		buf.WriteString("}")
+		return nil
+
+	case reflect.Slice:
+
+		if v.IsNil() {
+			fmt.Fprintf(buf, "%s(nil)", t)
+			return nil
+		}
+
+		if t.Elem().Kind() == reflect.Uint8 {
+			fmt.Fprintf(buf, "%s(%q)", t, v.Bytes())
+			return nil
+		}
+
+		fmt.Fprintf(buf, "%s{", t)
+
+		for i := 0; i < v.Len(); i++ {
+
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			err := writeValue(buf, v.Index(i), imports)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		buf.WriteString("}")
+		return nil
+
+	case reflect.Map:
+
+		if v.IsNil() {
+			fmt.Fprintf(buf, "%s(nil)", t)
+			return nil
+		}
+
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("Unable to render map with %s keys", t.Key())
+		}
+
+		map_keys := v.MapKeys()
+
+		sort.Slice(map_keys, func(i, j int) bool {
+			return map_keys[i].String() < map_keys[j].String()
+		})
+
+		fmt.Fprintf(buf, "%s{", t)
+
+		for i, k := range map_keys {
+
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			err := writeValue(buf, k, imports)
+
+			if err != nil {
+				return err
+			}
+
+			buf.WriteString(":")
+
+			err = writeValue(buf, v.MapIndex(k), imports)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		buf.WriteString("}")
+		return nil
+
+	default:
+		return fmt.Errorf("Unable to render value of type %s", t)
+	}
+}
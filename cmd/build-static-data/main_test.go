@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestRenderSourceExtras(t *testing.T) {
+
+	a := sfomuseum.Aircraft{
+		WOFID: 1,
+		Name:  "Test aircraft",
+		Extras: map[string]interface{}{
+			"test:int":   int64(1),
+			"test:float": float64(1),
+			"test:json":  json.RawMessage(`{"a":1}`),
+			"test:list":  []interface{}{float64(2), "two", true},
+		},
+	}
+
+	keys := map[string][]int{
+		"wof:1": []int{0},
+	}
+
+	src, err := renderSource("static", "sfomuseum", []interface{}{a}, keys)
+
+	if err != nil {
+		t.Fatalf("Failed to render source, %v", err)
+	}
+
+	_, err = parser.ParseFile(token.NewFileSet(), "sfomuseum_data.go", src, parser.AllErrors)
+
+	if err != nil {
+		t.Fatalf("Failed to parse rendered source, %v\n%s", err, src)
+	}
+
+	expected := []string{
+		`"encoding/json"`,
+		`"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"`,
+		`"test:int": int64(1)`,
+		`"test:float": float64(1)`,
+		`"test:json": json.RawMessage("{\"a\":1}")`,
+		`"test:list": []interface{}{float64(2), "two", true}`,
+	}
+
+	for _, str := range expected {
+
+		if !strings.Contains(string(src), str) {
+			t.Fatalf("Expected rendered source to contain %s\n%s", str, src)
+		}
+	}
+}
+
+func TestRenderSourceUnsupported(t *testing.T) {
+
+	a := sfomuseum.Aircraft{
+		WOFID: 1,
+		Extras: map[string]interface{}{
+			"test:chan": make(chan int),
+		},
+	}
+
+	_, err := renderSource("static", "sfomuseum", []interface{}{a}, map[string][]int{})
+
+	if err == nil {
+		t.Fatalf("Expected rendering an unsupported extra to fail")
+	}
+}
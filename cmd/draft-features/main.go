@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/draft"
+	"log"
+	"strings"
+)
+
+func main() {
+
+	target := flag.String("target", "drafts", "The directory to write draft features to.")
+	all := flag.Bool("all", false, "Generate draft features for every ICAO designator that has no corresponding SFO Museum aircraft.")
+	overwrite := flag.Bool("overwrite", false, "Replace existing draft features in -target.")
+
+	flag.Parse()
+
+	designators := make([]string, 0)
+
+	for _, designator := range flag.Args() {
+		designators = append(designators, strings.ToUpper(strings.TrimSpace(designator)))
+	}
+
+	if len(designators) == 0 && !*all {
+		log.Fatalf("Missing ICAO designators to draft. Pass one or more designators or use the -all flag.")
+	}
+
+	if len(designators) > 0 && *all {
+		log.Fatalf("ICAO designators and the -all flag are mutually exclusive.")
+	}
+
+	ctx := context.Background()
+
+	opts := &draft.Options{
+		Designators: designators,
+	}
+
+	drafts, existing, err := draft.DraftEmbeddedData(ctx, opts)
+
+	if err != nil {
+		log.Fatalf("Failed to draft features, %v", err)
+	}
+
+	for _, designator := range existing {
+		log.Printf("Skipped %s, which already has a corresponding SFO Museum aircraft\n", designator)
+	}
+
+	err = draft.WriteDrafts(*target, drafts, *overwrite)
+
+	if err != nil {
+		log.Fatalf("Failed to write draft features, %v", err)
+	}
+
+	log.Printf("Wrote %d draft features to %s\n", len(drafts), *target)
+}
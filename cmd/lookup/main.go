@@ -3,6 +3,7 @@ package main
 import (
 	_ "github.com/sfomuseum/go-sfomuseum-aircraft/icao"
 	_ "github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	_ "github.com/sfomuseum/go-sfomuseum-aircraft/static"
 )
 
 import (
@@ -15,7 +16,8 @@ import (
 
 func main() {
 
-	lookup_uri := flag.String("lookup-uri", "sfomuseum://", "Valid options are: icao://, sfomuseum://")
+	lookup_uri := flag.String("lookup-uri", "sfomuseum://", "Valid options are: icao://, sfomuseum://, static://icao, static://sfomuseum, cache://?lookup={LOOKUP_URI}, chain://?lookup={LOOKUP_URI}&lookup={LOOKUP_URI}")
+	wikidata := flag.Bool("wikidata", false, "Treat codes as Wikidata IDs (for example Q6425) and resolve them to their corresponding records. Only applies to sfomuseum:// lookups.")
 
 	flag.Parse()
 
@@ -28,6 +30,10 @@ func main() {
 
 	for _, code := range flag.Args() {
 
+		if *wikidata {
+			code = fmt.Sprintf("wd:id:%s", code)
+		}
+
 		results, err := lookup.Find(ctx, code)
 
 		if err != nil {
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/suggest"
+	"io"
+	"log"
+	"os"
+)
+
+func main() {
+
+	defaults := suggest.DefaultOptions()
+
+	max_candidates := flag.Int("max-candidates", defaults.MaxCandidates, "The maximum number of candidate designators to report for each aircraft.")
+	min_score := flag.Float64("min-score", defaults.MinScore, "The minimum score (0.0 - 1.0) a candidate designator must have to be reported.")
+	accept_threshold := flag.Float64("accept-threshold", 0.8, "The minimum score (0.0 - 1.0) for a candidate designator to be marked as accepted in the decisions file.")
+
+	report := flag.String("report", "", "The path to write a human-readable report of candidate designators. If empty the report is written to STDOUT.")
+	decisions := flag.String("decisions", "", "The path to write a CSV file of accept/reject decisions for catalogers to review. If empty no decisions file is written.")
+
+	flag.Parse()
+
+	ctx := context.Background()
+
+	opts := &suggest.Options{
+		MaxCandidates: *max_candidates,
+		MinScore:      *min_score,
+	}
+
+	suggestions, err := suggest.SuggestEmbeddedData(ctx, opts)
+
+	if err != nil {
+		log.Fatalf("Failed to derive suggestions, %v", err)
+	}
+
+	var report_wr io.Writer = os.Stdout
+
+	if *report != "" {
+
+		fh, err := os.Create(*report)
+
+		if err != nil {
+			log.Fatalf("Failed to open '%s', %v", *report, err)
+		}
+
+		defer fh.Close()
+		report_wr = fh
+	}
+
+	err = suggest.WriteReport(report_wr, suggestions)
+
+	if err != nil {
+		log.Fatalf("Failed to write report, %v", err)
+	}
+
+	if *decisions != "" {
+
+		fh, err := os.Create(*decisions)
+
+		if err != nil {
+			log.Fatalf("Failed to open '%s', %v", *decisions, err)
+		}
+
+		defer fh.Close()
+
+		err = suggest.WriteDecisions(fh, suggestions, *accept_threshold)
+
+		if err != nil {
+			log.Fatalf("Failed to write decisions, %v", err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/validate"
+	"log"
+	"os"
+)
+
+func main() {
+
+	as_json := flag.Bool("json", false, "Emit the report as JSON.")
+
+	flag.Parse()
+
+	ctx := context.Background()
+
+	report, err := validate.ValidateEmbeddedData(ctx)
+
+	if err != nil {
+		log.Fatalf("Failed to validate data, %v", err)
+	}
+
+	if *as_json {
+
+		enc := json.NewEncoder(os.Stdout)
+		err = enc.Encode(report)
+
+	} else {
+
+		err = report.Write(os.Stdout)
+	}
+
+	if err != nil {
+		log.Fatalf("Failed to write report, %v", err)
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
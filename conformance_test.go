@@ -0,0 +1,80 @@
+package aircraft_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"testing"
+)
+
+func conformanceOptions(prefix string, new_lookup func(context.Context) (aircraft.Lookup, error)) *aircrafttest.ConformanceOptions {
+
+	opts := &aircrafttest.ConformanceOptions{
+		NewLookup: new_lookup,
+		Codes:     []string{"B744", "wof:1159289391", "Q139289"},
+		Missing:   []string{"XXXX", "wof:0"},
+		NewRecord: func(i int) (string, interface{}) {
+			code := fmt.Sprintf("%s%02d", prefix, i)
+			return code, &sfomuseum.Aircraft{WOFID: int64(9990001000 + i), Name: code, ICAODesignator: code}
+		},
+	}
+
+	return opts
+}
+
+func TestCacheLookupConformance(t *testing.T) {
+
+	opts := conformanceOptions("ZC", func(ctx context.Context) (aircraft.Lookup, error) {
+		return aircraft.NewCacheLookupWithLookup(ctx, aircrafttest.NewSFOMuseumFixturesLookup(), 16, 0)
+	})
+
+	aircrafttest.RunConformance(t, opts)
+}
+
+func TestChainLookupConformance(t *testing.T) {
+
+	for _, mode := range []string{aircraft.CHAIN_MODE_FIRST, aircraft.CHAIN_MODE_ALL} {
+
+		t.Run(mode, func(t *testing.T) {
+
+			opts := conformanceOptions("ZH", func(ctx context.Context) (aircraft.Lookup, error) {
+
+				sources := []string{"local", "fixtures"}
+
+				lookups := []aircraft.Lookup{
+					aircrafttest.NewFakeLookup(nil, &sfomuseum.Aircraft{WOFID: 9990000999, ICAODesignator: "B744"}),
+					aircrafttest.NewSFOMuseumFixturesLookup(),
+				}
+
+				return aircraft.NewChainLookupWithLookups(ctx, sources, lookups, mode, 0)
+			})
+
+			aircrafttest.RunConformance(t, opts)
+		})
+	}
+}
+
+func TestOverlayLookupConformance(t *testing.T) {
+
+	opts := conformanceOptions("ZO", func(ctx context.Context) (aircraft.Lookup, error) {
+
+		overrides := []*aircraft.Override{
+			&aircraft.Override{Operation: aircraft.OVERRIDE_SUPPRESS, ID: "1528104577"},
+		}
+
+		identity := func(r interface{}) string {
+			return sfomuseum.Identity(r.(*sfomuseum.Aircraft))
+		}
+
+		return aircraft.NewOverlayLookupWithOverrides(ctx, aircrafttest.NewSFOMuseumFixturesLookup(), overrides, identity, aircrafttest.DefaultCodes)
+	})
+
+	// Q139289 is only shared by the suppressed record in the fixtures
+
+	opts.Codes = []string{"B744", "wof:1159289391"}
+	opts.Missing = append(opts.Missing, "B39M")
+
+	aircrafttest.RunConformance(t, opts)
+}
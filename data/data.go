@@ -1,8 +0,0 @@
-package data
-
-import (
-	"embed"
-)
-
-//go:embed *.json
-var FS embed.FS
@@ -0,0 +1,11 @@
+//go:build !aircraft_noicao && !aircraft_nosfomuseum
+// +build !aircraft_noicao,!aircraft_nosfomuseum
+
+package data
+
+// embedded maps the name of each dataset to a boolean value indicating whether it is expected to be embedded.
+// Both datasets are embedded by default.
+var embedded = map[string]bool{
+	"icao.json":      true,
+	"sfomuseum.json": true,
+}
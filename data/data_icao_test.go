@@ -0,0 +1,11 @@
+//go:build !aircraft_noicao && aircraft_nosfomuseum
+// +build !aircraft_noicao,aircraft_nosfomuseum
+
+package data
+
+// embedded maps the name of each dataset to a boolean value indicating whether it is expected to be embedded.
+// Only the ICAO dataset is embedded when built with the aircraft_nosfomuseum tag.
+var embedded = map[string]bool{
+	"icao.json":      true,
+	"sfomuseum.json": false,
+}
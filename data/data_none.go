@@ -0,0 +1,11 @@
+//go:build aircraft_noicao && aircraft_nosfomuseum
+// +build aircraft_noicao,aircraft_nosfomuseum
+
+package data
+
+import (
+	"embed"
+)
+
+// FS is empty because both datasets have been omitted by build tags.
+var FS embed.FS
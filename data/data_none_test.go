@@ -0,0 +1,11 @@
+//go:build aircraft_noicao && aircraft_nosfomuseum
+// +build aircraft_noicao,aircraft_nosfomuseum
+
+package data
+
+// embedded maps the name of each dataset to a boolean value indicating whether it is expected to be embedded.
+// Neither dataset is embedded when built with both the aircraft_noicao and aircraft_nosfomuseum tags.
+var embedded = map[string]bool{
+	"icao.json":      false,
+	"sfomuseum.json": false,
+}
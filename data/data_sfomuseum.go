@@ -0,0 +1,11 @@
+//go:build aircraft_noicao && !aircraft_nosfomuseum
+// +build aircraft_noicao,!aircraft_nosfomuseum
+
+package data
+
+import (
+	"embed"
+)
+
+//go:embed sfomuseum.json.gz
+var FS embed.FS
@@ -0,0 +1,61 @@
+// package data provides precompiled (embedded) ICAO and SFO Museum aircraft data.
+//
+// Data is embedded as gzip-compressed files (`icao.json.gz` and `sfomuseum.json.gz`). Either dataset can be omitted from a binary
+// by building with the `aircraft_noicao` or `aircraft_nosfomuseum` build tags respectively.
+package data
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Open will return an `io.ReadCloser` instance for the uncompressed contents of the embedded file `name` (for example "icao.json").
+// If a gzip-compressed version of `name` is embedded it will be decompressed transparently.
+func Open(name string) (io.ReadCloser, error) {
+
+	fh, err := FS.Open(name + ".gz")
+
+	if err != nil {
+
+		fh, err := FS.Open(name)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open %s (it may have been omitted by a build tag), %w", name, err)
+		}
+
+		return fh, nil
+	}
+
+	gz, err := gzip.NewReader(fh)
+
+	if err != nil {
+		fh.Close()
+		return nil, fmt.Errorf("Failed to create gzip reader for %s, %w", name, err)
+	}
+
+	r := &gzipReadCloser{
+		Reader: gz,
+		fh:     fh,
+	}
+
+	return r, nil
+}
+
+// gzipReadCloser closes both the gzip reader and its underlying file.
+type gzipReadCloser struct {
+	*gzip.Reader
+	fh io.Closer
+}
+
+func (r *gzipReadCloser) Close() error {
+
+	err := r.Reader.Close()
+
+	if err != nil {
+		r.fh.Close()
+		return err
+	}
+
+	return r.fh.Close()
+}
@@ -0,0 +1,53 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+
+	for name, ok := range embedded {
+
+		fh, err := Open(name)
+
+		if !ok {
+
+			if err == nil {
+				fh.Close()
+				t.Fatalf("Expected %s to have been omitted by a build tag", name)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Failed to open %s, %v", name, err)
+		}
+
+		var rows []map[string]interface{}
+
+		dec := json.NewDecoder(fh)
+		err = dec.Decode(&rows)
+
+		if err != nil {
+			t.Fatalf("Failed to decode %s, %v", name, err)
+		}
+
+		err = fh.Close()
+
+		if err != nil {
+			t.Fatalf("Failed to close %s, %v", name, err)
+		}
+
+		if len(rows) == 0 {
+			t.Fatalf("Expected %s to contain records", name)
+		}
+	}
+
+	_, err := Open("missing.json")
+
+	if err == nil {
+		t.Fatalf("Expected missing file to fail")
+	}
+}
@@ -0,0 +1,324 @@
+// package draft provides methods for generating draft Who's On First (WOF) style GeoJSON aircraft features for ICAO designators
+// that have no corresponding SFO Museum aircraft, for catalogers to review.
+package draft
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The repository that draft features are destined for.
+const DRAFT_REPO string = "sfomuseum-data-aircraft"
+
+// The longitude of the point geometry assigned to draft features, which is the same as that of other SFO Museum aircraft.
+const SFO_LONGITUDE float64 = -122.386
+
+// The latitude of the point geometry assigned to draft features, which is the same as that of other SFO Museum aircraft.
+const SFO_LATITUDE float64 = 37.616
+
+// Draft is a draft WOF-style GeoJSON aircraft feature for an ICAO designator.
+type Draft struct {
+	// The ICAO designator the draft feature was generated for.
+	Designator string
+	// The draft GeoJSON feature.
+	Feature *Feature
+}
+
+// Feature is a minimal GeoJSON feature.
+type Feature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *Geometry              `json:"geometry"`
+}
+
+// Geometry is a minimal GeoJSON point geometry.
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// Options defines configuration options for generating draft features.
+type Options struct {
+	// The list of ICAO designators to generate draft features for. If empty draft features are generated for every ICAO designator
+	// that has no corresponding SFO Museum aircraft.
+	Designators []string
+}
+
+// DraftEmbeddedData will generate draft features for ICAO designators in the precompiled (embedded) data in `data/icao.json` that
+// have no corresponding SFO Museum aircraft in the precompiled (embedded) data in `data/sfomuseum.json`. See `DraftFeatures` for details.
+func DraftEmbeddedData(ctx context.Context, opts *Options) ([]*Draft, []string, error) {
+
+	icao_aircraft, err := icao.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sfom_aircraft, err := sfomuseum.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return DraftFeatures(ctx, icao_aircraft, sfom_aircraft, opts)
+}
+
+// MissingDesignators returns the sorted list of ICAO designators in `icao_aircraft` that have no corresponding SFO Museum aircraft,
+// by `sfomuseum.Aircraft.ICAODesignator` or an `icao:designator` concordance, in `sfom_aircraft`.
+func MissingDesignators(icao_aircraft []*icao.Aircraft, sfom_aircraft []*sfomuseum.Aircraft) []string {
+
+	known := knownDesignators(sfom_aircraft)
+	missing := make([]string, 0)
+
+	for designator := range groupByDesignator(icao_aircraft) {
+
+		if !known[designator] {
+			missing = append(missing, designator)
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+// DraftFeatures will generate a draft feature for each of the ICAO designators defined in `opts`, using the records in `icao_aircraft`.
+// If `opts` is nil the default options are used.
+// Designators which already have a corresponding SFO Museum aircraft in `sfom_aircraft` are skipped and returned as the second value so
+// that they can be reported. An error is returned if a designator is not present in `icao_aircraft`. Draft features are not assigned
+// Who's On First IDs; that is left to catalogers once they have been reviewed.
+func DraftFeatures(ctx context.Context, icao_aircraft []*icao.Aircraft, sfom_aircraft []*sfomuseum.Aircraft, opts *Options) ([]*Draft, []string, error) {
+
+	by_designator := groupByDesignator(icao_aircraft)
+	known := knownDesignators(sfom_aircraft)
+
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	designators := opts.Designators
+
+	if len(designators) == 0 {
+		designators = MissingDesignators(icao_aircraft, sfom_aircraft)
+	}
+
+	drafts := make([]*Draft, 0)
+	existing := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, designator := range designators {
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+			// pass
+		}
+
+		if seen[designator] {
+			continue
+		}
+
+		seen[designator] = true
+
+		if known[designator] {
+			existing = append(existing, designator)
+			continue
+		}
+
+		rows, ok := by_designator[designator]
+
+		if !ok {
+			return nil, nil, fmt.Errorf("Unknown ICAO designator '%s'", designator)
+		}
+
+		d := &Draft{
+			Designator: designator,
+			Feature:    draftFeature(designator, rows),
+		}
+
+		drafts = append(drafts, d)
+	}
+
+	return drafts, existing, nil
+}
+
+// WriteDrafts will write each draft feature in `drafts` to `{root}/{DESIGNATOR}.geojson`. Existing files are not replaced unless
+// `overwrite` is true.
+func WriteDrafts(root string, drafts []*Draft, overwrite bool) error {
+
+	err := os.MkdirAll(root, 0755)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create %s, %w", root, err)
+	}
+
+	for _, d := range drafts {
+
+		path := filepath.Join(root, d.Designator+".geojson")
+
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+
+		if !overwrite {
+			flags = flags | os.O_EXCL
+		}
+
+		fh, err := os.OpenFile(path, flags, 0644)
+
+		if err != nil {
+			return fmt.Errorf("Failed to open %s, %w", path, err)
+		}
+
+		enc := json.NewEncoder(fh)
+		enc.SetIndent("", "  ")
+
+		err = enc.Encode(d.Feature)
+
+		if err != nil {
+			fh.Close()
+			return fmt.Errorf("Failed to write %s, %w", path, err)
+		}
+
+		err = fh.Close()
+
+		if err != nil {
+			return fmt.Errorf("Failed to close %s, %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// draftFeature returns a draft feature for `designator` derived from the ICAO records in `rows`. The first record's model name is
+// used as the feature's name and any other model names are recorded as variant names. Model names are trimmed of surrounding
+// whitespace, which is common in the ICAO data. A designator may be shared by more than one manufacturer so `icao:manufacturer_code`
+// is the list of every manufacturer in `rows`; the remaining ICAO properties describe the aircraft type and are the same for each row.
+func draftFeature(designator string, rows []*icao.Aircraft) *Feature {
+
+	first := rows[0]
+	name := strings.TrimSpace(first.ModelFullName)
+
+	props := map[string]interface{}{
+		"wof:name":                  name,
+		"wof:placetype":             "custom",
+		"wof:repo":                  DRAFT_REPO,
+		"sfomuseum:placetype":       "aircraft",
+		"sfomuseum:is_draft":        1,
+		"wof:concordances":          map[string]string{"icao:designator": designator},
+		"icao:designator":           designator,
+		"icao:manufacturer_code":    manufacturerCodes(rows),
+		"icao:description":          first.Description,
+		"icao:aircraft_description": first.AircraftDescription,
+		"icao:wtc":                  first.WTC,
+		"icao:engine_type":          first.EngineType,
+		"edtf:inception":            "uuuu",
+		"edtf:cessation":            "uuuu",
+		"name:eng_x_preferred":      []string{name},
+	}
+
+	engine_count, err := strconv.Atoi(first.EngineCount)
+
+	if err == nil {
+		props["icao:engine_count"] = engine_count
+	}
+
+	variants := make([]string, 0)
+	seen := map[string]bool{name: true}
+
+	for _, a := range rows[1:] {
+
+		variant := strings.TrimSpace(a.ModelFullName)
+
+		if variant == "" || seen[variant] {
+			continue
+		}
+
+		seen[variant] = true
+		variants = append(variants, variant)
+	}
+
+	if len(variants) > 0 {
+		props["name:eng_x_variant"] = variants
+	}
+
+	// Like other SFO Museum aircraft, draft features are placed at SFO
+
+	geom := &Geometry{
+		Type:        "Point",
+		Coordinates: []float64{SFO_LONGITUDE, SFO_LATITUDE},
+	}
+
+	f := &Feature{
+		Type:       "Feature",
+		Properties: props,
+		Geometry:   geom,
+	}
+
+	return f
+}
+
+// manufacturerCodes returns the distinct, trimmed manufacturer codes in `rows`, in the order they appear.
+func manufacturerCodes(rows []*icao.Aircraft) []string {
+
+	codes := make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, a := range rows {
+
+		code := strings.TrimSpace(a.ManufacturerCode)
+
+		if code == "" || seen[code] {
+			continue
+		}
+
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	return codes
+}
+
+// groupByDesignator returns the records in `icao_aircraft` grouped by designator, in the order they appear.
+func groupByDesignator(icao_aircraft []*icao.Aircraft) map[string][]*icao.Aircraft {
+
+	by_designator := make(map[string][]*icao.Aircraft)
+
+	for _, a := range icao_aircraft {
+
+		if a.Designator == "" {
+			continue
+		}
+
+		by_designator[a.Designator] = append(by_designator[a.Designator], a)
+	}
+
+	return by_designator
+}
+
+// knownDesignators returns the set of ICAO designators that SFO Museum aircraft in `sfom_aircraft` are associated with.
+func knownDesignators(sfom_aircraft []*sfomuseum.Aircraft) map[string]bool {
+
+	known := make(map[string]bool)
+
+	for _, a := range sfom_aircraft {
+
+		if a.ICAODesignator != "" {
+			known[a.ICAODesignator] = true
+		}
+
+		code, ok := a.Concordances["icao:designator"]
+
+		if ok && code != "" {
+			known[code] = true
+		}
+	}
+
+	return known
+}
@@ -0,0 +1,179 @@
+package draft
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var test_icao = []*icao.Aircraft{
+	&icao.Aircraft{Designator: "B722", ManufacturerCode: "BOEING", ModelFullName: "727-200", EngineCount: "3", EngineType: "Jet", WTC: "M", Description: "L3J"},
+	&icao.Aircraft{Designator: "B721", ManufacturerCode: "BOEING", ModelFullName: "727-100", EngineCount: "3", EngineType: "Jet", WTC: "M", Description: "L3J"},
+	&icao.Aircraft{Designator: "AS32", ManufacturerCode: "AEROSPATIALE", ModelFullName: "AS-332 Super Puma  ", EngineCount: "2", EngineType: "Turboshaft", WTC: "M", Description: "H2T"},
+	&icao.Aircraft{Designator: "AS32", ManufacturerCode: "EUROCOPTER", ModelFullName: "AS-332 Super Puma", EngineCount: "2", EngineType: "Turboshaft", WTC: "M", Description: "H2T"},
+	&icao.Aircraft{Designator: "AS32", ManufacturerCode: "NURTANIO", ModelFullName: "NAS-332 Super Puma", EngineCount: "2", EngineType: "Turboshaft", WTC: "M", Description: "H2T"},
+}
+
+var test_sfomuseum = []*sfomuseum.Aircraft{
+	&sfomuseum.Aircraft{WOFID: 1, Name: "Boeing 727-200", ICAODesignator: "B722"},
+	&sfomuseum.Aircraft{WOFID: 2, Name: "Boeing 727-100", Concordances: map[string]string{"icao:designator": "B721"}},
+}
+
+func TestMissingDesignators(t *testing.T) {
+
+	missing := MissingDesignators(test_icao, test_sfomuseum)
+
+	if len(missing) != 1 || missing[0] != "AS32" {
+		t.Fatalf("Unexpected missing designators: %v", missing)
+	}
+}
+
+func TestDraftFeatures(t *testing.T) {
+
+	ctx := context.Background()
+
+	drafts, existing, err := DraftFeatures(ctx, test_icao, test_sfomuseum, &Options{})
+
+	if err != nil {
+		t.Fatalf("Failed to draft features, %v", err)
+	}
+
+	if len(existing) != 0 {
+		t.Fatalf("Expected no existing designators, got %v", existing)
+	}
+
+	if len(drafts) != 1 {
+		t.Fatalf("Expected 1 draft, got %d", len(drafts))
+	}
+
+	props := drafts[0].Feature.Properties
+
+	if props["wof:name"] != "AS-332 Super Puma" {
+		t.Fatalf("Unexpected name: %v", props["wof:name"])
+	}
+
+	manufacturers, ok := props["icao:manufacturer_code"].([]string)
+
+	if !ok || len(manufacturers) != 3 || manufacturers[0] != "AEROSPATIALE" || manufacturers[1] != "EUROCOPTER" || manufacturers[2] != "NURTANIO" {
+		t.Fatalf("Unexpected manufacturers: %v", props["icao:manufacturer_code"])
+	}
+
+	if props["icao:engine_count"] != 2 {
+		t.Fatalf("Unexpected engine count: %v", props["icao:engine_count"])
+	}
+
+	concordances := props["wof:concordances"].(map[string]string)
+
+	if concordances["icao:designator"] != "AS32" {
+		t.Fatalf("Unexpected concordances: %v", concordances)
+	}
+
+	variants, ok := props["name:eng_x_variant"].([]string)
+
+	if !ok || len(variants) != 1 || variants[0] != "NAS-332 Super Puma" {
+		t.Fatalf("Unexpected variant names: %v", props["name:eng_x_variant"])
+	}
+
+	coords := drafts[0].Feature.Geometry.Coordinates
+
+	if coords[0] != SFO_LONGITUDE || coords[1] != SFO_LATITUDE {
+		t.Fatalf("Unexpected coordinates: %v", coords)
+	}
+
+	_, ok = props["wof:id"]
+
+	if ok {
+		t.Fatalf("Draft feature should not be assigned a WOF ID")
+	}
+
+	// Designators with existing SFO Museum aircraft are skipped and reported
+
+	drafts, existing, err = DraftFeatures(ctx, test_icao, test_sfomuseum, &Options{Designators: []string{"B722", "AS32"}})
+
+	if err != nil {
+		t.Fatalf("Failed to draft features, %v", err)
+	}
+
+	if len(drafts) != 1 || drafts[0].Designator != "AS32" {
+		t.Fatalf("Unexpected drafts for B722, AS32")
+	}
+
+	if len(existing) != 1 || existing[0] != "B722" {
+		t.Fatalf("Expected B722 to be reported as existing, got %v", existing)
+	}
+
+	_, _, err = DraftFeatures(ctx, test_icao, test_sfomuseum, &Options{Designators: []string{"ZZZZ"}})
+
+	if err == nil {
+		t.Fatalf("Expected unknown designator to fail")
+	}
+}
+
+func TestDraftFeaturesNilOptions(t *testing.T) {
+
+	ctx := context.Background()
+
+	drafts, _, err := DraftFeatures(ctx, test_icao, test_sfomuseum, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to draft features with nil options, %v", err)
+	}
+
+	if len(drafts) != 1 || drafts[0].Designator != "AS32" {
+		t.Fatalf("Unexpected drafts with nil options: %v", drafts)
+	}
+}
+
+func TestWriteDrafts(t *testing.T) {
+
+	ctx := context.Background()
+
+	drafts, _, err := DraftFeatures(ctx, test_icao, test_sfomuseum, &Options{})
+
+	if err != nil {
+		t.Fatalf("Failed to draft features, %v", err)
+	}
+
+	root := t.TempDir()
+
+	err = WriteDrafts(root, drafts, false)
+
+	if err != nil {
+		t.Fatalf("Failed to write drafts, %v", err)
+	}
+
+	path := filepath.Join(root, "AS32.geojson")
+	body, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, %v", path, err)
+	}
+
+	var f Feature
+
+	err = json.Unmarshal(body, &f)
+
+	if err != nil {
+		t.Fatalf("Failed to unmarshal %s, %v", path, err)
+	}
+
+	if f.Type != "Feature" || f.Properties["wof:name"] != "AS-332 Super Puma" {
+		t.Fatalf("Unexpected feature in %s", path)
+	}
+
+	err = WriteDrafts(root, drafts, false)
+
+	if err == nil {
+		t.Fatalf("Expected existing draft to not be replaced")
+	}
+
+	err = WriteDrafts(root, drafts, true)
+
+	if err != nil {
+		t.Fatalf("Failed to overwrite drafts, %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package aircraft
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NotFoundError is the error returned by `Lookup.Find` when there are no records matching a code.
+type NotFoundError struct {
+	Code string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("Code '%s' not found", e.Code)
+}
+
+// NotFound returns a new `NotFoundError` for `code`.
+func NotFound(code string) error {
+	return &NotFoundError{Code: code}
+}
+
+// IsNotFound returns a boolean value indicating whether `err` is, or wraps, a `NotFoundError`.
+func IsNotFound(err error) bool {
+	var nf *NotFoundError
+	return errors.As(err, &nf)
+}
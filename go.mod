@@ -3,8 +3,11 @@ module github.com/sfomuseum/go-sfomuseum-aircraft
 go 1.16
 
 require (
+	github.com/aaronland/go-json-query v0.1.0
 	github.com/aaronland/go-roster v0.0.2
+	github.com/sfomuseum/go-edtf v0.2.3
 	github.com/sfomuseum/go-sfomuseum-geojson v0.1.2
+	github.com/tidwall/gjson v1.7.5
 	github.com/whosonfirst/go-whosonfirst-geojson-v2 v0.16.3
 	github.com/whosonfirst/go-whosonfirst-iterate v1.2.0
 	github.com/whosonfirst/go-whosonfirst-uri v1.1.0
@@ -0,0 +1,67 @@
+package icao_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"testing"
+)
+
+func TestICAOLookupConformance(t *testing.T) {
+
+	opts := &aircrafttest.ConformanceOptions{
+		NewLookup: func(ctx context.Context) (aircraft.Lookup, error) {
+
+			// Use a lookup with its own lookup table so that appended records
+			// do not leak in to other tests
+
+			aircraft_list, err := icao.LoadEmbeddedData(ctx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return icao.NewLookupWithLookupFunc(ctx, icao.NewLookupFuncWithAircraft(ctx, aircraft_list))
+		},
+		Codes:   []string{"B744", "designator:A320", "manufacturer:BOEING"},
+		Missing: []string{"XXXX", "designator:BOEING"},
+		NewRecord: func(i int) (string, interface{}) {
+			code := fmt.Sprintf("ZI%02d", i)
+			return code, &icao.Aircraft{Designator: code, ManufacturerCode: "EXAMPLE"}
+		},
+	}
+
+	aircrafttest.RunConformance(t, opts)
+}
+
+func TestLookupFuncOutsidePackage(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := func(ctx context.Context) (*icao.LookupTable, error) {
+
+		table := icao.NewLookupTable()
+
+		err := table.Append(ctx, &icao.Aircraft{Designator: "ZX01", ManufacturerCode: "EXAMPLE"})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return table, nil
+	}
+
+	lu, err := icao.NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	results, err := lu.Find(ctx, "designator:ZX01")
+
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Failed to find record appended by lookup func, %v", err)
+	}
+}
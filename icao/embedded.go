@@ -0,0 +1,32 @@
+package icao
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/data"
+)
+
+// LoadEmbeddedData will return the list of `Aircraft` derived from precompiled (embedded) data in `data/icao.json`.
+func LoadEmbeddedData(ctx context.Context) ([]*Aircraft, error) {
+
+	fh, err := data.Open("icao.json")
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load data, %w", err)
+	}
+
+	defer fh.Close()
+
+	aircraft_list := make([]*Aircraft, 0)
+
+	err = readAircraft(ctx, fh, func(data *Aircraft) error {
+		aircraft_list = append(aircraft_list, data)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode data, %w", err)
+	}
+
+	return aircraft_list, nil
+}
@@ -0,0 +1,104 @@
+package icao
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"sort"
+)
+
+// BaseType returns the base type of `designator`, which is the designator without its final (variant) character. For example
+// the base type of "B744" is "B74". ICAO designators are not formally hierarchical so this is a heuristic which is only applied
+// to four-character designators; for all other designators an empty string is returned.
+func BaseType(designator string) string {
+
+	if len(designator) != 4 {
+		return ""
+	}
+
+	return designator[0:3]
+}
+
+// FindFamily will return the list of aircraft sharing a base type, and a manufacturer, with the aircraft matching `code`. Matching
+// rules are the same as `Find`. The list includes the aircraft matching `code`. See `BaseType` for details.
+func (l *ICAOLookup) FindFamily(ctx context.Context, code string) ([]*Aircraft, error) {
+	return l.findFamily(ctx, code, false)
+}
+
+// FindSiblings will return the list of aircraft sharing a base type, and a manufacturer, with the aircraft matching `code` but with
+// a different designator. Matching rules are the same as `Find`. See `BaseType` for details.
+func (l *ICAOLookup) FindSiblings(ctx context.Context, code string) ([]*Aircraft, error) {
+	return l.findFamily(ctx, code, true)
+}
+
+func (l *ICAOLookup) findFamily(ctx context.Context, code string, exclude_self bool) ([]*Aircraft, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*Aircraft, 0)
+
+	ok := l.table.find(code, func(a *Aircraft) bool {
+		matches = append(matches, a)
+		return true
+	})
+
+	if !ok {
+		return nil, aircraft.NotFound(code)
+	}
+
+	designators := make(map[string]bool)
+	families := make(map[string]map[string]bool)
+
+	for _, a := range matches {
+
+		designators[a.Designator] = true
+
+		base := BaseType(a.Designator)
+
+		if base == "" || a.ManufacturerCode == "" {
+			continue
+		}
+
+		_, ok := families[a.ManufacturerCode]
+
+		if !ok {
+			families[a.ManufacturerCode] = make(map[string]bool)
+		}
+
+		families[a.ManufacturerCode][base] = true
+	}
+
+	manufacturers := make([]string, 0, len(families))
+
+	for m := range families {
+		manufacturers = append(manufacturers, m)
+	}
+
+	sort.Strings(manufacturers)
+
+	results := make([]*Aircraft, 0)
+
+	for _, manufacturer := range manufacturers {
+
+		bases := families[manufacturer]
+
+		l.table.find("manufacturer:"+manufacturer, func(a *Aircraft) bool {
+
+			if !bases[BaseType(a.Designator)] {
+				return true
+			}
+
+			if exclude_self && designators[a.Designator] {
+				return true
+			}
+
+			results = append(results, a)
+			return true
+		})
+	}
+
+	return results, nil
+}
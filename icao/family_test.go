@@ -0,0 +1,86 @@
+package icao
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"testing"
+)
+
+func TestBaseType(t *testing.T) {
+
+	tests := map[string]string{
+		"B744": "B74",
+		"A388": "A38",
+		"C17":  "",
+		"":     "",
+	}
+
+	for designator, expected := range tests {
+
+		base := BaseType(designator)
+
+		if base != expected {
+			t.Fatalf("Invalid base type for '%s', expected '%s' but got '%s'", designator, expected, base)
+		}
+	}
+}
+
+func TestICAOLookupFamily(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := aircraft.NewLookup(ctx, "icao://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	icao_lu := lu.(*ICAOLookup)
+
+	family, err := icao_lu.FindFamily(ctx, "B744")
+
+	if err != nil {
+		t.Fatalf("Failed to find family for B744, %v", err)
+	}
+
+	found := make(map[string]bool)
+
+	for _, a := range family {
+
+		if BaseType(a.Designator) != "B74" || a.ManufacturerCode != "BOEING" {
+			t.Fatalf("Invalid family member for B744, %s", a)
+		}
+
+		found[a.Designator] = true
+	}
+
+	for _, designator := range []string{"B741", "B742", "B744", "B748"} {
+
+		if !found[designator] {
+			t.Fatalf("Expected %s to be in the B744 family", designator)
+		}
+	}
+
+	siblings, err := icao_lu.FindSiblings(ctx, "B744")
+
+	if err != nil {
+		t.Fatalf("Failed to find siblings for B744, %v", err)
+	}
+
+	if len(siblings) == 0 {
+		t.Fatalf("Expected B744 to have siblings")
+	}
+
+	for _, a := range siblings {
+
+		if a.Designator == "B744" {
+			t.Fatalf("Expected B744 to be excluded from its siblings")
+		}
+	}
+
+	_, err = icao_lu.FindFamily(ctx, "XXXX")
+
+	if !aircraft.IsNotFound(err) {
+		t.Fatalf("Expected XXXX to not be found, %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package icao
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/internal/binindex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+
+	ctx := context.Background()
+
+	aircraft_list, err := LoadEmbeddedData(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load data, %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "icao.idx")
+
+	fh, err := os.Create(path)
+
+	if err != nil {
+		t.Fatalf("Failed to create index, %v", err)
+	}
+
+	err = WriteIndex(fh, aircraft_list)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	fh.Close()
+
+	idx, err := binindex.Open(path, true)
+
+	if err != nil {
+		t.Fatalf("Failed to open index, %v", err)
+	}
+
+	defer idx.Close()
+
+	memory_table := NewLookupTable()
+
+	for _, a := range aircraft_list {
+		appendData(ctx, memory_table, a)
+	}
+
+	index_table := newLookupTableWithIndex(idx)
+
+	for _, code := range []string{"B744", "designator:A320", "manufacturer:BOEING"} {
+
+		expected := findRows(memory_table, code)
+		results := findRows(index_table, code)
+
+		if len(results) == 0 || len(results) != len(expected) {
+			t.Fatalf("Expected %d results for '%s', got %d", len(expected), code, len(results))
+		}
+
+		for i, a := range results {
+
+			if *a != *expected[i] {
+				t.Fatalf("Unexpected result for '%s', expected %s but got %s", code, expected[i], a)
+			}
+		}
+	}
+
+	a := &Aircraft{
+		Designator:       "ZZ33",
+		ManufacturerCode: "BOEING",
+	}
+
+	appendData(ctx, index_table, a)
+
+	if index_table.count("manufacturer:BOEING") != memory_table.count("manufacturer:BOEING")+1 {
+		t.Fatalf("Expected appended record to be indexed alongside binary index")
+	}
+
+	if len(findRows(index_table, "ZZ33")) != 1 {
+		t.Fatalf("Failed to find appended record")
+	}
+}
+
+func TestIndexLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	// Ensure the default lookup table has been loaded so that we can verify
+	// that the index is not ignored in favour of it
+
+	_, err := NewLookup(ctx, "icao://")
+
+	if err != nil {
+		t.Fatalf("Failed to create default lookup, %v", err)
+	}
+
+	aircraft_list := []*Aircraft{
+		&Aircraft{Designator: "XI01", ManufacturerCode: "EXAMPLE", ModelFullName: "Index 1"},
+		&Aircraft{Designator: "XI02", ManufacturerCode: "EXAMPLE", ModelFullName: "Index 2"},
+	}
+
+	path := filepath.Join(t.TempDir(), "icao.idx")
+
+	fh, err := os.Create(path)
+
+	if err != nil {
+		t.Fatalf("Failed to create index, %v", err)
+	}
+
+	err = WriteIndex(fh, aircraft_list)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	fh.Close()
+
+	for _, use_mmap := range []bool{false, true} {
+
+		uri := fmt.Sprintf("icao://index?path=%s&mmap=%t", path, use_mmap)
+
+		lu, err := NewLookup(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create lookup for %s, %v", uri, err)
+		}
+
+		results, err := lu.Find(ctx, "manufacturer:EXAMPLE")
+
+		if err != nil {
+			t.Fatalf("Failed to find EXAMPLE in %s, %v", uri, err)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results for EXAMPLE in %s, got %d", uri, len(results))
+		}
+
+		_, err = lu.Find(ctx, "B744")
+
+		if err == nil {
+			t.Fatalf("Expected B744 to not be found in %s", uri)
+		}
+	}
+}
@@ -2,182 +2,291 @@ package icao
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/sfomuseum/go-sfomuseum-aircraft"
 	"github.com/sfomuseum/go-sfomuseum-aircraft/data"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/internal/jsonstream"
 	"io"
 	_ "log"
-	"strings"
+	"net/url"
+	"strconv"
 	"sync"
-	"sync/atomic"
 )
 
-var lookup_table *sync.Map
-var lookup_idx int64
+// lookup_table is the lookup table for the precompiled (embedded) data which is shared by all the lookups that use it.
+var lookup_table *LookupTable
 
-var lookup_init sync.Once
-var lookup_init_err error
+// lookup_mu guards the initialization of lookup_table
+var lookup_mu sync.Mutex
 
-type ICAOLookupFunc func(context.Context)
+// ICAOLookupFunc is a function that, when invoked, returns a lookup table populated with aircraft data.
+type ICAOLookupFunc func(context.Context) (*LookupTable, error)
 
 type ICAOLookup struct {
 	aircraft.Lookup
+	table *LookupTable
 }
 
 func init() {
 	ctx := context.Background()
 	aircraft.RegisterLookup(ctx, "icao", NewLookup)
-
-	lookup_idx = int64(0)
 }
 
-// NewLookup will return an `aircraft.Lookup` instance derived from precompiled (embedded) data in `data/icao.json`
+// NewLookup will return an `aircraft.Lookup` instance. By default the lookup table is derived from precompiled (embedded) data in `data/icao.json`
+// by passing in `icao://` as the URI. It is also possible to create a new lookup table with the following URI options:
+//	`icao://index?path={PATH}&mmap={BOOLEAN}`
+// This will cause the lookup table to be derived from a precompiled binary index (as produced by the `build-icao-data` tool) stored at `{PATH}`. If `{BOOLEAN}` is true the index will be memory-mapped rather than read in to memory.
+//	`icao://overlay?overrides={PATH}&lookup={LOOKUP_URI}`
+// This will cause the overrides (add, replace and suppress operations keyed on the value returned by `Identity`) stored at `{PATH}` to be applied on top of the lookup defined by `{LOOKUP_URI}`. If `{LOOKUP_URI}` is empty the default `icao://` lookup is used. See `ReadOverrides` for details.
 func NewLookup(ctx context.Context, uri string) (aircraft.Lookup, error) {
 
-	fs := data.FS
-	fh, err := fs.Open("icao.json")
+	u, err := url.Parse(uri)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to load data, %v", err)
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	// Reminder: u.Scheme is used by the aircraft.Lookup constructor
+
+	if u.Host == "index" {
+
+		q := u.Query()
+
+		path := q.Get("path")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?path= parameter")
+		}
+
+		use_mmap, err := parseBool(q.Get("mmap"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?mmap= parameter, %w", err)
+		}
+
+		lookup_func := NewLookupFuncWithIndex(ctx, path, use_mmap)
+		return NewLookupWithLookupFunc(ctx, lookup_func)
 	}
 
-	lookup_func := NewLookupFuncWithReader(ctx, fh)
-	return NewLookupWithLookupFunc(ctx, lookup_func)
+	if u.Host == "overlay" {
+
+		q := u.Query()
+
+		path := q.Get("overrides")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?overrides= parameter")
+		}
+
+		lookup_uri := q.Get("lookup")
+
+		if lookup_uri == "" {
+			lookup_uri = "icao://"
+		}
+
+		return newOverlayLookupFromPath(ctx, lookup_uri, path)
+	}
+
+	return newDefaultLookup(ctx)
 }
 
 // NewLookup will return an `ICAOLookupFunc` function instance that, when invoked, will populate an `aircraft.Lookup` instance with data stored in `r`.
 // `r` will be closed when the `ICAOLookupFunc` function instance is invoked.
-// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/icao.json`.
+// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/icao.json`, either as
+// a JSON array or as JSON Lines (one record per line). Records are indexed as they are decoded. If the context passed to the `ICAOLookupFunc` is
+// cancelled before all the records have been indexed the lookup will fail with the context's error.
 func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) ICAOLookupFunc {
 
-	lookup_func := func(ctx context.Context) {
+	lookup_func := func(ctx context.Context) (*LookupTable, error) {
 
 		defer r.Close()
 
-		var aircraft []*Aircraft
+		table := NewLookupTable()
 
-		dec := json.NewDecoder(r)
-		err := dec.Decode(&aircraft)
+		err := readAircraft(ctx, r, func(data *Aircraft) error {
+			return appendData(ctx, table, data)
+		})
 
 		if err != nil {
-			lookup_init_err = err
-			return
+			return nil, fmt.Errorf("Failed to load aircraft data, %w", err)
 		}
 
-		table := new(sync.Map)
+		table.compact()
+		return table, nil
+	}
+
+	return lookup_func
+}
+
+// NewLookupFuncWithAircraft will return an `ICAOLookupFunc` function instance that, when invoked, will populate an `aircraft.Lookup` instance with data stored in `aircraft_list`.
+// If the context passed to the `ICAOLookupFunc` is cancelled before all the records have been indexed the lookup will fail with the context's error.
+func NewLookupFuncWithAircraft(ctx context.Context, aircraft_list []*Aircraft) ICAOLookupFunc {
 
-		for _, data := range aircraft {
+	lookup_func := func(ctx context.Context) (*LookupTable, error) {
 
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				// pass
+		table := NewLookupTable()
+
+		for _, data := range aircraft_list {
+
+			err := ctx.Err()
+
+			if err != nil {
+				return nil, err
 			}
 
 			appendData(ctx, table, data)
 		}
 
-		lookup_table = table
+		table.compact()
+		return table, nil
 	}
 
 	return lookup_func
 }
 
-// NewLookupWithLookupFunc will return an `aircraft.Lookup` instance derived by data compiled using `lookup_func`.
-func NewLookupWithLookupFunc(ctx context.Context, lookup_func ICAOLookupFunc) (aircraft.Lookup, error) {
+// readAircraft will decode each `Aircraft` record in `r`, which may be a JSON array or JSON Lines, and pass it to `cb`.
+func readAircraft(ctx context.Context, r io.Reader, cb func(*Aircraft) error) error {
 
-	fn := func() {
-		lookup_func(ctx)
+	new_func := func() interface{} {
+		return new(Aircraft)
 	}
 
-	lookup_init.Do(fn)
-
-	if lookup_init_err != nil {
-		return nil, lookup_init_err
+	record_func := func(v interface{}) error {
+		return cb(v.(*Aircraft))
 	}
 
-	l := ICAOLookup{}
-	return &l, nil
+	return jsonstream.Decode(ctx, r, new_func, record_func)
 }
 
-func (l *ICAOLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+// NewLookupWithLookupFunc will return an `aircraft.Lookup` instance derived by data compiled using `lookup_func`. Each lookup created
+// this way has its own lookup table.
+func NewLookupWithLookupFunc(ctx context.Context, lookup_func ICAOLookupFunc) (aircraft.Lookup, error) {
 
-	pointers, ok := lookup_table.Load(code)
+	table, err := lookup_func(ctx)
 
-	if !ok {
-		return nil, errors.New("Not found")
+	if err != nil {
+		return nil, err
+	}
+
+	l := ICAOLookup{
+		table: table,
 	}
 
-	aircraft := make([]interface{}, 0)
+	return &l, nil
+}
 
-	for _, p := range pointers.([]string) {
+// newDefaultLookup will return an `aircraft.Lookup` instance derived from the precompiled (embedded) data in `data/icao.json`. The data is
+// only loaded once, by the first successful call, and is shared by all the lookups created this way. If loading fails, for example
+// because `ctx` was cancelled, the error is returned and the next call will try again.
+func newDefaultLookup(ctx context.Context) (aircraft.Lookup, error) {
 
-		if !strings.HasPrefix(p, "pointer:") {
-			return nil, errors.New("Invalid pointer")
+	lookup_mu.Lock()
+	defer lookup_mu.Unlock()
+
+	if lookup_table == nil {
+
+		fh, err := data.Open("icao.json")
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
 		}
 
-		row, ok := lookup_table.Load(p)
+		table, err := NewLookupFuncWithReader(ctx, fh)(ctx)
 
-		if !ok {
-			return nil, errors.New("Invalid pointer")
+		if err != nil {
+			return nil, err
 		}
 
-		aircraft = append(aircraft, row.(*Aircraft))
+		lookup_table = table
 	}
 
-	return aircraft, nil
+	l := ICAOLookup{
+		table: lookup_table,
+	}
+
+	return &l, nil
 }
 
-func (l *ICAOLookup) Append(ctx context.Context, data interface{}) error {
-	return appendData(ctx, lookup_table, data.(*Aircraft))
+// Find will return the list of aircraft matching `code` which may be an ICAO designator or a manufacturer code. Codes may be
+// qualified by the kind of identifier they are to avoid ambiguous matches: "designator:B744" or "manufacturer:BOEING".
+func (l *ICAOLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0, l.table.count(code))
+
+	ok := l.table.find(code, func(a *Aircraft) bool {
+		results = append(results, a)
+		return true
+	})
+
+	if !ok {
+		return nil, aircraft.NotFound(code)
+	}
+
+	return results, nil
 }
 
-func appendData(ctx context.Context, table *sync.Map, data *Aircraft) error {
+// FindFunc will invoke `cb` for each aircraft matching `code`, stopping if `cb` returns false. Unlike `Find` it does not allocate
+// a new list of results so it is the preferred method for performance-sensitive code. Matching rules are the same as `Find`.
+func (l *ICAOLookup) FindFunc(ctx context.Context, code string, cb func(*Aircraft) bool) error {
 
-	idx := atomic.AddInt64(&lookup_idx, 1)
+	err := ctx.Err()
 
-	pointer := fmt.Sprintf("pointer:%d", idx)
-	table.Store(pointer, data)
+	if err != nil {
+		return err
+	}
+
+	ok := l.table.find(code, cb)
 
-	possible_codes := []string{
-		data.Designator,
-		data.ManufacturerCode,
+	if !ok {
+		return aircraft.NotFound(code)
 	}
 
-	for _, code := range possible_codes {
+	return nil
+}
 
-		if code == "" {
-			continue
-		}
+// Append will add `data` to the lookup's table. Lookups derived from the precompiled (embedded) data share a single table so
+// records appended to one of them will be returned by all of them.
+func (l *ICAOLookup) Append(ctx context.Context, data interface{}) error {
+	return appendData(ctx, l.table, data.(*Aircraft))
+}
 
-		pointers := make([]string, 0)
-		has_pointer := false
+func appendData(ctx context.Context, table *LookupTable, data *Aircraft) error {
 
-		others, ok := table.Load(code)
+	possible_codes := LookupCodes(data)
+	table.append(data, possible_codes...)
 
-		if ok {
+	return nil
+}
 
-			pointers = others.([]string)
-		}
+// LookupCodes returns the list of codes that `data` should be indexed by. Each identifier is indexed both unqualified and
+// qualified by its kind (for example "B744" and "designator:B744").
+func LookupCodes(data *Aircraft) []string {
 
-		for _, dupe := range pointers {
+	codes := make([]string, 0)
 
-			if dupe == pointer {
-				has_pointer = true
-				break
-			}
-		}
+	if data.Designator != "" {
+		codes = append(codes, data.Designator, "designator:"+data.Designator)
+	}
 
-		if has_pointer {
-			continue
-		}
+	if data.ManufacturerCode != "" {
+		codes = append(codes, data.ManufacturerCode, "manufacturer:"+data.ManufacturerCode)
+	}
+
+	return codes
+}
 
-		pointers = append(pointers, pointer)
-		table.Store(code, pointers)
+// parseBool is a wrapper around strconv.ParseBool that treats an empty string as false.
+func parseBool(str string) (bool, error) {
+
+	if str == "" {
+		return false, nil
 	}
 
-	return nil
+	return strconv.ParseBool(str)
 }
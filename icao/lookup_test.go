@@ -0,0 +1,134 @@
+package icao
+
+import (
+	"context"
+	"errors"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestICAOLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu, err := aircraft.NewLookup(ctx, "icao://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	for _, code := range []string{"B744", "designator:B744"} {
+
+		results, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		for _, r := range results {
+
+			a := r.(*Aircraft)
+
+			if a.Designator != "B744" {
+				t.Fatalf("Invalid match for '%s', %s", code, a)
+			}
+		}
+	}
+
+	results, err := lu.Find(ctx, "manufacturer:BOEING")
+
+	if err != nil {
+		t.Fatalf("Unable to find manufacturer, %v", err)
+	}
+
+	for _, r := range results {
+
+		a := r.(*Aircraft)
+
+		if a.ManufacturerCode != "BOEING" {
+			t.Fatalf("Invalid match for manufacturer, %s", a)
+		}
+	}
+
+	_, err = lu.Find(ctx, "designator:BOEING")
+
+	if err == nil {
+		t.Fatalf("Expected qualified designator query for manufacturer code to fail")
+	}
+}
+
+func TestNewLookupFuncWithReader(t *testing.T) {
+
+	ctx := context.Background()
+
+	jsonl := `{"Designator":"XTST","ManufacturerCode":"EXAMPLE","ModelFullName":"Test 1"}
+{"Designator":"XTST","ManufacturerCode":"EXAMPLE","ModelFullName":"Test 2"}
+`
+
+	lookup_func := NewLookupFuncWithReader(ctx, ioutil.NopCloser(strings.NewReader(jsonl)))
+	table, err := lookup_func(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load JSON Lines data, %v", err)
+	}
+
+	results := findRows(table, "designator:XTST")
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results for XTST, got %d", len(results))
+	}
+
+	cancelled_ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	lookup_func = NewLookupFuncWithReader(ctx, ioutil.NopCloser(strings.NewReader(jsonl)))
+	_, err = lookup_func(cancelled_ctx)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewLookupCancelled(t *testing.T) {
+
+	// The embedded data is loaded in to the package-level lookup table so
+	// reset it, and restore it once the test has finished
+
+	lookup_mu.Lock()
+	table := lookup_table
+	lookup_table = nil
+	lookup_mu.Unlock()
+
+	defer func() {
+		lookup_mu.Lock()
+		lookup_table = table
+		lookup_mu.Unlock()
+	}()
+
+	ctx := context.Background()
+
+	cancelled_ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := NewLookup(cancelled_ctx, "icao://")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// A cancelled load should not prevent subsequent lookups from being created
+
+	lu, err := NewLookup(ctx, "icao://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup after cancelled load, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "B744")
+
+	if err != nil {
+		t.Fatalf("Failed to find B744, %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package icao
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/internal/binindex"
+	"sync"
+)
+
+// LookupTable is a compact, read-optimized index of `Aircraft` records. Each record is stored once, in the order it was appended,
+// and each code maps to a posting list of integer offsets into that list of records. All mutations happen while holding an exclusive
+// lock so that concurrent calls to `append` never lose each other's updates. A table may also be backed by a precompiled, read-only
+// binary index whose records are decoded on demand; records appended to such a table are stored in memory alongside it. Tables
+// are returned by `ICAOLookupFunc` functions; functions defined outside this package create them using `NewLookupTable` and `Append`.
+type LookupTable struct {
+	mu           *sync.RWMutex
+	records      []*Aircraft
+	postings     map[string][]int32
+	base         *binindex.Index
+	base_mu      *sync.Mutex
+	base_records []*Aircraft
+}
+
+// NewLookupTable returns a new, empty `LookupTable` instance.
+func NewLookupTable() *LookupTable {
+
+	t := &LookupTable{
+		mu:       new(sync.RWMutex),
+		records:  make([]*Aircraft, 0),
+		postings: make(map[string][]int32),
+	}
+
+	return t
+}
+
+func newLookupTableWithIndex(idx *binindex.Index) *LookupTable {
+
+	t := NewLookupTable()
+	t.base = idx
+	t.base_mu = new(sync.Mutex)
+	t.base_records = make([]*Aircraft, idx.Len())
+
+	return t
+}
+
+// lookup returns the list of records and the posting list for `code`. Both lists are snapshots: subsequent calls to `append`
+// only ever write past the end of a list that has been handed out so it is safe to read them without holding a lock.
+func (t *LookupTable) lookup(code string) ([]*Aircraft, []int32, bool) {
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	postings, ok := t.postings[code]
+	return t.records, postings, ok
+}
+
+// find invokes `cb` for each record indexed by `code`, stopping if `cb` returns false. It returns false if `code` is not indexed.
+func (t *LookupTable) find(code string, cb func(*Aircraft) bool) bool {
+
+	found := false
+
+	if t.base != nil {
+
+		postings, ok := t.base.Lookup(code)
+
+		if ok {
+
+			found = true
+
+			for i := 0; i < postings.Len(); i++ {
+
+				a, err := t.baseRecord(postings.At(i))
+
+				// The index's checksum has already been verified so this
+				// should never happen in practice
+
+				if err != nil {
+					continue
+				}
+
+				if !cb(a) {
+					return true
+				}
+			}
+		}
+	}
+
+	records, postings, ok := t.lookup(code)
+
+	if !ok {
+		return found
+	}
+
+	for _, offset := range postings {
+
+		if !cb(records[offset]) {
+			break
+		}
+	}
+
+	return true
+}
+
+// count returns the number of records indexed by `code`.
+func (t *LookupTable) count(code string) int {
+
+	count := 0
+
+	if t.base != nil {
+
+		postings, ok := t.base.Lookup(code)
+
+		if ok {
+			count += postings.Len()
+		}
+	}
+
+	_, postings, _ := t.lookup(code)
+	count += len(postings)
+
+	return count
+}
+
+// baseRecord returns the i-th record in the table's binary index, decoding and caching it if necessary.
+func (t *LookupTable) baseRecord(i int) (*Aircraft, error) {
+
+	t.base_mu.Lock()
+	defer t.base_mu.Unlock()
+
+	a := t.base_records[i]
+
+	if a != nil {
+		return a, nil
+	}
+
+	var data *Aircraft
+
+	enc, err := t.base.Record(i)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(enc, &data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.base_records[i] = data
+	return data, nil
+}
+
+// Append will add `data` to the table, indexed by each of its `LookupCodes`.
+func (t *LookupTable) Append(ctx context.Context, data *Aircraft) error {
+	return appendData(ctx, t, data)
+}
+
+// append indexes `data` by each of `codes`, atomically. A record is only indexed once for any given code.
+func (t *LookupTable) append(data *Aircraft, codes ...string) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	offset := int32(len(t.records))
+	t.records = append(t.records, data)
+
+	for _, code := range codes {
+
+		if code == "" {
+			continue
+		}
+
+		postings := t.postings[code]
+
+		if len(postings) > 0 && postings[len(postings)-1] == offset {
+			continue
+		}
+
+		t.postings[code] = append(postings, offset)
+	}
+}
+
+// compact packs all of the posting lists into a single, exactly-sized backing array and trims any excess capacity from the list
+// of records. It is meant to be called once after a table has been bulk-loaded.
+func (t *LookupTable) compact() {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := 0
+
+	for _, postings := range t.postings {
+		count += len(postings)
+	}
+
+	arena := make([]int32, count)
+	start := 0
+
+	for code, postings := range t.postings {
+
+		end := start + copy(arena[start:], postings)
+
+		// The three-index slice caps each list at its own length so that a
+		// subsequent append reallocates rather than overwriting its neighbour
+
+		t.postings[code] = arena[start:end:end]
+		start = end
+	}
+
+	records := make([]*Aircraft, len(t.records))
+	copy(records, t.records)
+
+	t.records = records
+}
@@ -0,0 +1,233 @@
+package icao
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// syncMapTable is the sync.Map based table that LookupTable replaced, in which records are stored under "pointer:{N}" keys and
+// each code maps to a list of pointers. It is retained, for benchmarking only, as a baseline for LookupTable.
+type syncMapTable struct {
+	rows *sync.Map
+	idx  int64
+}
+
+func newSyncMapTable(aircraft_list []*Aircraft) *syncMapTable {
+
+	t := &syncMapTable{
+		rows: new(sync.Map),
+	}
+
+	for _, a := range aircraft_list {
+		t.append(a, LookupCodes(a)...)
+	}
+
+	return t
+}
+
+func (t *syncMapTable) append(data *Aircraft, codes ...string) {
+
+	t.idx += 1
+
+	pointer := fmt.Sprintf("pointer:%d", t.idx)
+	t.rows.Store(pointer, data)
+
+	for _, code := range codes {
+
+		pointers := make([]string, 0)
+
+		others, ok := t.rows.Load(code)
+
+		if ok {
+			pointers = others.([]string)
+		}
+
+		t.rows.Store(code, append(pointers, pointer))
+	}
+}
+
+func (t *syncMapTable) find(code string) []*Aircraft {
+
+	pointers, ok := t.rows.Load(code)
+
+	if !ok {
+		return nil
+	}
+
+	rows := make([]*Aircraft, 0)
+
+	for _, p := range pointers.([]string) {
+		row, _ := t.rows.Load(p)
+		rows = append(rows, row.(*Aircraft))
+	}
+
+	return rows
+}
+
+func loadBenchmarkData(b *testing.B) []*Aircraft {
+
+	ctx := context.Background()
+
+	aircraft_list, err := LoadEmbeddedData(ctx)
+
+	if err != nil {
+		b.Fatalf("Failed to load data, %v", err)
+	}
+
+	return aircraft_list
+}
+
+func newBenchmarkTable(aircraft_list []*Aircraft) *LookupTable {
+
+	ctx := context.Background()
+	table := NewLookupTable()
+
+	for _, a := range aircraft_list {
+		appendData(ctx, table, a)
+	}
+
+	table.compact()
+	return table
+}
+
+// BenchmarkLookupTableLoad reports the cost of building a table from the embedded data as well as the
+// amount of memory the finished table retains (excluding the records themselves) as "retained-B".
+func BenchmarkLookupTableLoad(b *testing.B) {
+
+	aircraft_list := loadBenchmarkData(b)
+
+	var before runtime.MemStats
+	var after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	table := newBenchmarkTable(aircraft_list)
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	runtime.KeepAlive(table)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		newBenchmarkTable(aircraft_list)
+	}
+
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc), "retained-B")
+}
+
+// BenchmarkSyncMapTableLoad is identical to BenchmarkLookupTableLoad but for the sync.Map based table that LookupTable replaced.
+func BenchmarkSyncMapTableLoad(b *testing.B) {
+
+	aircraft_list := loadBenchmarkData(b)
+
+	var before runtime.MemStats
+	var after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	table := newSyncMapTable(aircraft_list)
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	runtime.KeepAlive(table)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		newSyncMapTable(aircraft_list)
+	}
+
+	b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc), "retained-B")
+}
+
+func BenchmarkLookupTableFind(b *testing.B) {
+
+	table := newBenchmarkTable(loadBenchmarkData(b))
+
+	cb := func(a *Aircraft) bool {
+		return true
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		table.find("manufacturer:BOEING", cb)
+	}
+}
+
+func BenchmarkSyncMapTableFind(b *testing.B) {
+
+	table := newSyncMapTable(loadBenchmarkData(b))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		table.find("manufacturer:BOEING")
+	}
+}
+
+func BenchmarkICAOLookupFind(b *testing.B) {
+
+	ctx := context.Background()
+
+	lu, err := NewLookup(ctx, "icao://")
+
+	if err != nil {
+		b.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		_, err := lu.Find(ctx, "B744")
+
+		if err != nil {
+			b.Fatalf("Failed to find B744, %v", err)
+		}
+	}
+}
+
+func BenchmarkICAOLookupFindFunc(b *testing.B) {
+
+	ctx := context.Background()
+
+	lu, err := NewLookup(ctx, "icao://")
+
+	if err != nil {
+		b.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	icao_lu := lu.(*ICAOLookup)
+	count := 0
+
+	cb := func(a *Aircraft) bool {
+		count += 1
+		return true
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+
+		err := icao_lu.FindFunc(ctx, "manufacturer:BOEING", cb)
+
+		if err != nil {
+			b.Fatalf("Failed to find BOEING, %v", err)
+		}
+	}
+}
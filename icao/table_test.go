@@ -0,0 +1,166 @@
+package icao
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"sync"
+	"testing"
+)
+
+func findRows(table *LookupTable, code string) []*Aircraft {
+
+	rows := make([]*Aircraft, 0)
+
+	table.find(code, func(a *Aircraft) bool {
+		rows = append(rows, a)
+		return true
+	})
+
+	return rows
+}
+
+// newTestLookup returns a lookup, derived from the embedded data, with its own lookup table so that tests may append records to it
+// without affecting the lookup table shared by other lookups.
+func newTestLookup(t *testing.T, ctx context.Context) aircraft.Lookup {
+
+	aircraft_list, err := LoadEmbeddedData(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load data, %v", err)
+	}
+
+	lu, err := NewLookupWithLookupFunc(ctx, NewLookupFuncWithAircraft(ctx, aircraft_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	return lu
+}
+
+func TestLookupTableConcurrentAppend(t *testing.T) {
+
+	ctx := context.Background()
+	table := NewLookupTable()
+
+	writers := 16
+	records := 100
+
+	wg := new(sync.WaitGroup)
+
+	for w := 0; w < writers; w++ {
+
+		wg.Add(2)
+
+		go func(w int) {
+
+			defer wg.Done()
+
+			for i := 0; i < records; i++ {
+
+				a := &Aircraft{
+					Designator:       fmt.Sprintf("T%d", i%10),
+					ManufacturerCode: "STRESS",
+					ModelFullName:    fmt.Sprintf("%d-%d", w, i),
+				}
+
+				appendData(ctx, table, a)
+			}
+		}(w)
+
+		go func() {
+
+			defer wg.Done()
+
+			for i := 0; i < records; i++ {
+
+				rows := findRows(table, "manufacturer:STRESS")
+
+				for _, a := range rows {
+
+					if a.ManufacturerCode != "STRESS" {
+						t.Errorf("Invalid record, %s", a)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	rows := findRows(table, "STRESS")
+
+	if len(rows) != writers*records {
+		t.Fatalf("Expected %d records, got %d", writers*records, len(rows))
+	}
+
+	total := 0
+
+	for i := 0; i < 10; i++ {
+		rows := findRows(table, fmt.Sprintf("designator:T%d", i))
+		total += len(rows)
+	}
+
+	if total != writers*records {
+		t.Fatalf("Expected %d records indexed by designator, got %d", writers*records, total)
+	}
+}
+
+func TestICAOLookupConcurrentAppend(t *testing.T) {
+
+	ctx := context.Background()
+
+	lu := newTestLookup(t, ctx)
+
+	writers := 8
+	records := 50
+
+	wg := new(sync.WaitGroup)
+
+	for w := 0; w < writers; w++ {
+
+		wg.Add(2)
+
+		go func(w int) {
+
+			defer wg.Done()
+
+			for i := 0; i < records; i++ {
+
+				a := &Aircraft{
+					Designator:       "ZZ31",
+					ManufacturerCode: "STRESS031",
+					ModelFullName:    fmt.Sprintf("%d-%d", w, i),
+				}
+
+				err := lu.Append(ctx, a)
+
+				if err != nil {
+					t.Errorf("Failed to append record, %v", err)
+				}
+			}
+		}(w)
+
+		go func() {
+
+			defer wg.Done()
+
+			for i := 0; i < records; i++ {
+				lu.Find(ctx, "ZZ31")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	results, err := lu.Find(ctx, "manufacturer:STRESS031")
+
+	if err != nil {
+		t.Fatalf("Failed to find records, %v", err)
+	}
+
+	if len(results) != writers*records {
+		t.Fatalf("Expected %d records, got %d", writers*records, len(results))
+	}
+}
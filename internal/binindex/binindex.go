@@ -0,0 +1,404 @@
+// package binindex implements a compact, versioned and checksummed binary format for precompiled lookup tables.
+//
+// An index consists of a fixed-size header followed by five sections:
+//
+//	record offsets: (record_count + 1) little-endian uint32 offsets into the records section
+//	key entries:    key_count entries of four little-endian uint32 values (key offset, key length, posting offset, posting count), sorted by key
+//	postings:       posting_count little-endian uint32 record numbers
+//	keys:           the bytes of every key, concatenated
+//	records:        the bytes of every record (JSON-encoded), concatenated
+//
+// Keys are sorted so that an index can be queried with a binary search directly against its bytes, which in turn means an index
+// can be memory-mapped and used without being decoded.
+package binindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// The current version of the binary index format.
+const VERSION uint32 = 1
+
+// The size, in bytes, of the binary index header.
+const HEADER_SIZE int = 36
+
+// The magic bytes at the start of every binary index.
+var MAGIC = []byte("SFOMAIDX")
+
+// The size, in bytes, of a key entry.
+const key_entry_size int = 16
+
+// Index is a read-only binary index.
+type Index struct {
+	data         []byte
+	record_count int
+	key_count    int
+	offsets      []byte
+	entries      []byte
+	postings     []byte
+	keys         []byte
+	records      []byte
+	close_func   func() error
+}
+
+// Postings is a list of record numbers associated with a key.
+type Postings []byte
+
+// Len returns the number of record numbers in p.
+func (p Postings) Len() int {
+	return len(p) / 4
+}
+
+// At returns the i-th record number in p.
+func (p Postings) At(i int) int {
+	return int(binary.LittleEndian.Uint32(p[i*4:]))
+}
+
+// Write will write a binary index derived from `records` and `keys` to `wr`. `keys` maps each key to the (zero-indexed) list of
+// `records` it should resolve to.
+func Write(wr io.Writer, records [][]byte, keys map[string][]int) error {
+
+	sorted := make([]string, 0, len(keys))
+
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+
+	sort.Strings(sorted)
+
+	var offsets_buf bytes.Buffer
+	var entries_buf bytes.Buffer
+	var postings_buf bytes.Buffer
+	var keys_buf bytes.Buffer
+	var records_buf bytes.Buffer
+
+	offset := uint32(0)
+
+	for _, r := range records {
+		binary.Write(&offsets_buf, binary.LittleEndian, offset)
+		records_buf.Write(r)
+		offset += uint32(len(r))
+	}
+
+	binary.Write(&offsets_buf, binary.LittleEndian, offset)
+
+	posting_count := 0
+
+	for _, k := range sorted {
+
+		entry := []uint32{
+			uint32(keys_buf.Len()),
+			uint32(len(k)),
+			uint32(posting_count),
+			uint32(len(keys[k])),
+		}
+
+		binary.Write(&entries_buf, binary.LittleEndian, entry)
+		keys_buf.WriteString(k)
+
+		for _, i := range keys[k] {
+
+			if i < 0 || i >= len(records) {
+				return fmt.Errorf("Invalid record number %d for key '%s'", i, k)
+			}
+
+			binary.Write(&postings_buf, binary.LittleEndian, uint32(i))
+			posting_count += 1
+		}
+	}
+
+	sections := [][]byte{
+		offsets_buf.Bytes(),
+		entries_buf.Bytes(),
+		postings_buf.Bytes(),
+		keys_buf.Bytes(),
+		records_buf.Bytes(),
+	}
+
+	checksum := crc32.NewIEEE()
+
+	for _, s := range sections {
+		checksum.Write(s)
+	}
+
+	header := make([]byte, HEADER_SIZE)
+	copy(header, MAGIC)
+
+	binary.LittleEndian.PutUint32(header[8:], VERSION)
+	binary.LittleEndian.PutUint32(header[12:], uint32(len(records)))
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(sorted)))
+	binary.LittleEndian.PutUint32(header[20:], uint32(posting_count))
+	binary.LittleEndian.PutUint32(header[24:], uint32(keys_buf.Len()))
+	binary.LittleEndian.PutUint32(header[28:], uint32(records_buf.Len()))
+	binary.LittleEndian.PutUint32(header[32:], checksum.Sum32())
+
+	_, err := wr.Write(header)
+
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sections {
+
+		_, err := wr.Write(s)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Parse will return a new `Index` instance backed by `data`. The header and checksum of `data` are validated, as is every offset and
+// length in the index, before the index is returned so that querying it never reads outside of `data`. `data` must not be modified
+// while the index is in use.
+func Parse(data []byte) (*Index, error) {
+
+	if len(data) < HEADER_SIZE {
+		return nil, errors.New("Index is too short")
+	}
+
+	if !bytes.Equal(data[0:len(MAGIC)], MAGIC) {
+		return nil, errors.New("Invalid index, missing magic bytes")
+	}
+
+	version := binary.LittleEndian.Uint32(data[8:])
+
+	if version != VERSION {
+		return nil, fmt.Errorf("Unsupported index version %d, expected %d", version, VERSION)
+	}
+
+	record_count := binary.LittleEndian.Uint32(data[12:])
+	key_count := binary.LittleEndian.Uint32(data[16:])
+	posting_count := binary.LittleEndian.Uint32(data[20:])
+	keys_len := binary.LittleEndian.Uint32(data[24:])
+	records_len := binary.LittleEndian.Uint32(data[28:])
+	checksum := binary.LittleEndian.Uint32(data[32:])
+
+	// Sizes are calculated as uint64 values so that they can not
+	// overflow, even on platforms where an int is 32 bits
+
+	sizes := []uint64{
+		(uint64(record_count) + 1) * 4,
+		uint64(key_count) * uint64(key_entry_size),
+		uint64(posting_count) * 4,
+		uint64(keys_len),
+		uint64(records_len),
+	}
+
+	expected := uint64(HEADER_SIZE)
+
+	for _, sz := range sizes {
+		expected += sz
+	}
+
+	if uint64(len(data)) != expected {
+		return nil, fmt.Errorf("Invalid index length, expected %d bytes but got %d", expected, len(data))
+	}
+
+	body := data[HEADER_SIZE:]
+
+	if crc32.ChecksumIEEE(body) != checksum {
+		return nil, errors.New("Invalid index, checksum mismatch")
+	}
+
+	sections := make([][]byte, len(sizes))
+	start := uint64(0)
+
+	for i, sz := range sizes {
+		sections[i] = body[start : start+sz]
+		start += sz
+	}
+
+	idx := &Index{
+		data:         data,
+		record_count: int(record_count),
+		key_count:    int(key_count),
+		offsets:      sections[0],
+		entries:      sections[1],
+		postings:     sections[2],
+		keys:         sections[3],
+		records:      sections[4],
+	}
+
+	err := idx.validate()
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid index, %w", err)
+	}
+
+	return idx, nil
+}
+
+// validate ensures that every record offset, key entry and posting in the index refers to data inside its section, and that keys
+// are sorted, so that `Record` and `Lookup` can use them without further checks.
+func (idx *Index) validate() error {
+
+	records_len := uint64(len(idx.records))
+	prev := uint64(0)
+
+	for i := 0; i <= idx.record_count; i++ {
+
+		offset := uint64(binary.LittleEndian.Uint32(idx.offsets[i*4:]))
+
+		if i == 0 && offset != 0 {
+			return fmt.Errorf("first record offset is %d, expected 0", offset)
+		}
+
+		if offset < prev || offset > records_len {
+			return fmt.Errorf("record offset %d (%d) is out of range", i, offset)
+		}
+
+		prev = offset
+	}
+
+	if prev != records_len {
+		return fmt.Errorf("last record offset is %d, expected %d", prev, records_len)
+	}
+
+	keys_len := uint64(len(idx.keys))
+	postings_count := uint64(len(idx.postings) / 4)
+
+	var prev_key []byte
+
+	for i := 0; i < idx.key_count; i++ {
+
+		entry := idx.entries[i*key_entry_size:]
+
+		key_offset := uint64(binary.LittleEndian.Uint32(entry[0:]))
+		key_len := uint64(binary.LittleEndian.Uint32(entry[4:]))
+		posting_offset := uint64(binary.LittleEndian.Uint32(entry[8:]))
+		posting_count := uint64(binary.LittleEndian.Uint32(entry[12:]))
+
+		if key_offset+key_len > keys_len {
+			return fmt.Errorf("key %d is out of range", i)
+		}
+
+		if posting_offset+posting_count > postings_count {
+			return fmt.Errorf("postings for key %d are out of range", i)
+		}
+
+		key := idx.keys[key_offset : key_offset+key_len]
+
+		if i > 0 && bytes.Compare(prev_key, key) >= 0 {
+			return fmt.Errorf("key %d is not sorted", i)
+		}
+
+		prev_key = key
+	}
+
+	for i := uint64(0); i < postings_count; i++ {
+
+		r := binary.LittleEndian.Uint32(idx.postings[i*4:])
+
+		if uint64(r) >= uint64(idx.record_count) {
+			return fmt.Errorf("posting %d refers to record %d which is out of range", i, r)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of records in the index.
+func (idx *Index) Len() int {
+	return idx.record_count
+}
+
+// Record returns the bytes of the i-th record in the index. The bytes returned must not be modified. An error is returned if `i` is
+// not a valid record number.
+func (idx *Index) Record(i int) ([]byte, error) {
+
+	if i < 0 || i >= idx.record_count {
+		return nil, fmt.Errorf("Invalid record number %d", i)
+	}
+
+	start := binary.LittleEndian.Uint32(idx.offsets[i*4:])
+	end := binary.LittleEndian.Uint32(idx.offsets[(i+1)*4:])
+
+	return idx.records[start:end], nil
+}
+
+// Lookup returns the list of record numbers associated with `key` and a boolean value indicating whether `key` exists in the index.
+// The key entries it reads are validated by `Parse`.
+func (idx *Index) Lookup(key string) (Postings, bool) {
+
+	lo := 0
+	hi := idx.key_count
+
+	for lo < hi {
+
+		mid := int(uint(lo+hi) >> 1)
+		entry := idx.entries[mid*key_entry_size:]
+
+		key_offset := binary.LittleEndian.Uint32(entry[0:])
+		key_len := binary.LittleEndian.Uint32(entry[4:])
+
+		candidate := idx.keys[key_offset : key_offset+key_len]
+
+		switch compare(candidate, key) {
+		case 0:
+
+			posting_offset := binary.LittleEndian.Uint32(entry[8:])
+			posting_count := binary.LittleEndian.Uint32(entry[12:])
+
+			start := posting_offset * 4
+			end := start + (posting_count * 4)
+
+			return Postings(idx.postings[start:end]), true
+
+		case -1:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+
+	return nil, false
+}
+
+// Close releases any resources (for example a memory-mapped file) associated with the index.
+func (idx *Index) Close() error {
+
+	if idx.close_func == nil {
+		return nil
+	}
+
+	return idx.close_func()
+}
+
+// compare is equivalent to bytes.Compare(a, []byte(b)) without converting `b`.
+func compare(a []byte, b string) int {
+
+	n := len(a)
+
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for i := 0; i < n; i++ {
+
+		if a[i] < b[i] {
+			return -1
+		}
+
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return 0
+	}
+}
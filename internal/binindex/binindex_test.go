@@ -0,0 +1,178 @@
+package binindex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+
+	records := [][]byte{
+		[]byte(`{"id":0}`),
+		[]byte(`{"id":1}`),
+		[]byte(`{"id":2}`),
+	}
+
+	keys := map[string][]int{
+		"a":   []int{0, 2},
+		"b":   []int{1},
+		"abc": []int{2},
+		"":    []int{0},
+	}
+
+	var buf bytes.Buffer
+
+	err := Write(&buf, records, keys)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.idx")
+
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write index file, %v", err)
+	}
+
+	for _, use_mmap := range []bool{false, true} {
+
+		idx, err := Open(path, use_mmap)
+
+		if err != nil {
+			t.Fatalf("Failed to open index (mmap %t), %v", use_mmap, err)
+		}
+
+		if idx.Len() != len(records) {
+			t.Fatalf("Expected %d records, got %d", len(records), idx.Len())
+		}
+
+		for k, expected := range keys {
+
+			postings, ok := idx.Lookup(k)
+
+			if !ok {
+				t.Fatalf("Failed to find key '%s'", k)
+			}
+
+			if postings.Len() != len(expected) {
+				t.Fatalf("Expected %d postings for '%s', got %d", len(expected), k, postings.Len())
+			}
+
+			for i, r := range expected {
+
+				if postings.At(i) != r {
+					t.Fatalf("Expected posting %d for '%s' to be %d, got %d", i, k, r, postings.At(i))
+				}
+
+				enc, err := idx.Record(r)
+
+				if err != nil {
+					t.Fatalf("Failed to read record %d, %v", r, err)
+				}
+
+				if !bytes.Equal(enc, records[r]) {
+					t.Fatalf("Unexpected record %d, %s", r, enc)
+				}
+			}
+		}
+
+		for _, k := range []string{"ab", "c", "0"} {
+
+			_, ok := idx.Lookup(k)
+
+			if ok {
+				t.Fatalf("Did not expect to find key '%s'", k)
+			}
+		}
+
+		for _, r := range []int{-1, len(records)} {
+
+			_, err := idx.Record(r)
+
+			if err == nil {
+				t.Fatalf("Expected reading record %d to fail", r)
+			}
+		}
+
+		err = idx.Close()
+
+		if err != nil {
+			t.Fatalf("Failed to close index, %v", err)
+		}
+	}
+
+	corrupt := buf.Bytes()
+	corrupt[len(corrupt)-2] = 'X'
+
+	_, err = Parse(corrupt)
+
+	if err == nil {
+		t.Fatalf("Expected checksum error")
+	}
+
+	_, err = Parse(corrupt[0:10])
+
+	if err == nil {
+		t.Fatalf("Expected length error")
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+
+	records := [][]byte{
+		[]byte(`{"id":0}`),
+		[]byte(`{"id":1}`),
+	}
+
+	keys := map[string][]int{
+		"a": []int{0},
+		"b": []int{0, 1},
+	}
+
+	var buf bytes.Buffer
+
+	err := Write(&buf, records, keys)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	// Offsets of the values to corrupt, relative to the start of the index. Record offsets
+	// start after the header, key entries after the (len(records) + 1) record offsets and
+	// postings after the key entries.
+
+	entries := HEADER_SIZE + (len(records)+1)*4
+	postings := entries + len(keys)*key_entry_size
+
+	tests := map[string][2]int{
+		"record count":   [2]int{12, 0xffffffff},
+		"record offset":  [2]int{HEADER_SIZE + 4, 1000},
+		"key length":     [2]int{entries + key_entry_size + 4, 1000},
+		"posting count":  [2]int{entries + key_entry_size + 12, 1000},
+		"posting record": [2]int{postings, 99},
+	}
+
+	for label, tt := range tests {
+
+		data := make([]byte, buf.Len())
+		copy(data, buf.Bytes())
+
+		binary.LittleEndian.PutUint32(data[tt[0]:], uint32(tt[1]))
+
+		// Recalculate the checksum so that only the bounds checks can catch the corruption
+
+		binary.LittleEndian.PutUint32(data[32:], crc32.ChecksumIEEE(data[HEADER_SIZE:]))
+
+		_, err := Parse(data)
+
+		if err == nil {
+			t.Fatalf("Expected index with invalid %s to fail", label)
+		}
+	}
+}
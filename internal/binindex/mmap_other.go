@@ -0,0 +1,20 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package binindex
+
+import (
+	"os"
+)
+
+// openMmap falls back to reading the file at `path` in to memory on platforms where memory-mapping is not supported.
+func openMmap(path string) (*Index, error) {
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
@@ -0,0 +1,52 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package binindex
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func openMmap(path string) (*Index, error) {
+
+	fh, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fh.Close()
+
+	info, err := fh.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	size := int(info.Size())
+
+	if size == 0 {
+		return Parse(nil)
+	}
+
+	data, err := syscall.Mmap(int(fh.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to mmap %s, %w", path, err)
+	}
+
+	idx, err := Parse(data)
+
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+
+	idx.close_func = func() error {
+		return syscall.Munmap(data)
+	}
+
+	return idx, nil
+}
@@ -0,0 +1,23 @@
+package binindex
+
+import (
+	"os"
+)
+
+// Open will return a new `Index` instance derived from the file at `path`. If `use_mmap` is true, and memory-mapping is supported
+// on the current platform, the file is memory-mapped rather than read into memory. Callers should invoke the index's `Close`
+// method when they are finished with it.
+func Open(path string, use_mmap bool) (*Index, error) {
+
+	if use_mmap {
+		return openMmap(path)
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
@@ -0,0 +1,113 @@
+// package jsonstream implements streaming decoding of records encoded as either a JSON array or JSON Lines.
+package jsonstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// NewFunc returns a new (empty) record for a value to be decoded in to.
+type NewFunc func() interface{}
+
+// RecordFunc is invoked for each record once it has been decoded.
+type RecordFunc func(interface{}) error
+
+// Decode will decode each record in `r` in turn, invoking `new_func` to allocate the record and `record_func` once it has been
+// decoded. `r` may contain either a JSON array of records or one JSON record per line (JSON Lines); the format is determined by
+// the first non-whitespace character in `r`. If `ctx` is cancelled decoding stops and the context's error is returned.
+func Decode(ctx context.Context, r io.Reader, new_func NewFunc, record_func RecordFunc) error {
+
+	br := bufio.NewReader(r)
+
+	is_array, err := isArray(br)
+
+	if err != nil {
+
+		if err == io.EOF {
+			return nil
+		}
+
+		return err
+	}
+
+	dec := json.NewDecoder(br)
+
+	if is_array {
+
+		_, err := dec.Token()
+
+		if err != nil {
+			return fmt.Errorf("Failed to read start of array, %w", err)
+		}
+	}
+
+	for i := 0; ; i++ {
+
+		err := ctx.Err()
+
+		if err != nil {
+			return err
+		}
+
+		if is_array && !dec.More() {
+			break
+		}
+
+		v := new_func()
+		err = dec.Decode(v)
+
+		if err == io.EOF && !is_array {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to decode record %d, %w", i, err)
+		}
+
+		err = record_func(v)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if is_array {
+
+		_, err := dec.Token()
+
+		if err != nil {
+			return fmt.Errorf("Failed to read end of array, %w", err)
+		}
+	}
+
+	return nil
+}
+
+// isArray returns a boolean value indicating whether the first non-whitespace character in `br` is the start of a JSON array.
+func isArray(br *bufio.Reader) (bool, error) {
+
+	for {
+
+		r, _, err := br.ReadRune()
+
+		if err != nil {
+			return false, err
+		}
+
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		err = br.UnreadRune()
+
+		if err != nil {
+			return false, err
+		}
+
+		return r == '[', nil
+	}
+}
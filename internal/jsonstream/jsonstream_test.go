@@ -0,0 +1,84 @@
+package jsonstream
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	Name string
+}
+
+func decodeNames(ctx context.Context, str string) ([]string, error) {
+
+	names := make([]string, 0)
+
+	new_func := func() interface{} {
+		return new(record)
+	}
+
+	record_func := func(v interface{}) error {
+		names = append(names, v.(*record).Name)
+		return nil
+	}
+
+	err := Decode(ctx, strings.NewReader(str), new_func, record_func)
+	return names, err
+}
+
+func TestDecode(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]int{
+		`[{"Name":"a"},{"Name":"b"},{"Name":"c"}]`: 3,
+		"\n  [ {\"Name\":\"a\"} ]\n":               1,
+		`[]`:                                       0,
+		"{\"Name\":\"a\"}\n{\"Name\":\"b\"}\n":     2,
+		"{\"Name\":\"a\"}\n\n{\"Name\":\"b\"}\n{\"Name\":\"c\"}": 3,
+		"":   0,
+		"\n": 0,
+	}
+
+	for str, count := range tests {
+
+		names, err := decodeNames(ctx, str)
+
+		if err != nil {
+			t.Fatalf("Failed to decode %q, %v", str, err)
+		}
+
+		if len(names) != count {
+			t.Fatalf("Expected %d records for %q, got %d", count, str, len(names))
+		}
+	}
+
+	invalid := []string{
+		`[{"Name":"a"},`,
+		`[{"Name":"a"} {"Name":"b"}]`,
+		"{\"Name\":\"a\"}\n{\"Name\":",
+	}
+
+	for _, str := range invalid {
+
+		_, err := decodeNames(ctx, str)
+
+		if err == nil {
+			t.Fatalf("Expected %q to fail", str)
+		}
+	}
+}
+
+func TestDecodeCancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := decodeNames(ctx, `[{"Name":"a"}]`)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package aircraft
+
+import (
+	"context"
+	"fmt"
+)
+
+// OVERRIDE_ADD is the operation for an override that adds a new record.
+const OVERRIDE_ADD string = "add"
+
+// OVERRIDE_REPLACE is the operation for an override that replaces an existing record.
+const OVERRIDE_REPLACE string = "replace"
+
+// OVERRIDE_SUPPRESS is the operation for an override that removes an existing record.
+const OVERRIDE_SUPPRESS string = "suppress"
+
+// Override is a single correction applied by an `OverlayLookup` instance.
+type Override struct {
+	// One of `OVERRIDE_ADD`, `OVERRIDE_REPLACE` or `OVERRIDE_SUPPRESS`.
+	Operation string
+	// The identity of the record to replace or suppress. It is ignored for `OVERRIDE_ADD` operations.
+	ID string
+	// The record to add or to replace the existing record with. It is ignored for `OVERRIDE_SUPPRESS` operations.
+	Record interface{}
+}
+
+// IdentityFunc returns the unique identity of a record, or an empty string if the record has none.
+type IdentityFunc func(interface{}) string
+
+// CodesFunc returns the list of codes that a record should be indexed by.
+type CodesFunc func(interface{}) []string
+
+// OverlayLookup is an `aircraft.Lookup` implementation that applies a list of overrides on top of the results of another
+// `aircraft.Lookup` instance. Overrides are keyed on record identity, as determined by an `IdentityFunc`, so a replaced record is
+// returned for its own codes rather than the codes of the record it replaces. An added record with the same identity as a record in
+// the underlying lookup is treated as a replacement for it.
+//
+// Added and replacement records are only matched by the exact codes returned by a `CodesFunc` and are always returned. Rules that
+// the underlying lookup applies to its own records, for example falling back to a record's name or omitting records that are no
+// longer current, are not applied to them.
+type OverlayLookup struct {
+	Lookup
+	lookup   Lookup
+	identity IdentityFunc
+	hidden   map[string]bool
+	records  map[string][]interface{}
+}
+
+// NewOverlayLookupWithOverrides will return an `OverlayLookup` instance that applies `overrides`, in order, on top of `lookup`.
+// `identity` is used to determine the identity of both the records returned by `lookup` and the records in `overrides`. `codes`
+// is used to index the records in `overrides`.
+func NewOverlayLookupWithOverrides(ctx context.Context, lookup Lookup, overrides []*Override, identity IdentityFunc, codes CodesFunc) (*OverlayLookup, error) {
+
+	hidden := make(map[string]bool)
+
+	added := make(map[string]interface{})
+	order := make([]string, 0)
+
+	for i, o := range overrides {
+
+		switch o.Operation {
+		case OVERRIDE_ADD, OVERRIDE_REPLACE:
+
+			if o.Record == nil {
+				return nil, fmt.Errorf("Override %d (%s) is missing a record", i, o.Operation)
+			}
+
+			id := identity(o.Record)
+
+			if id == "" {
+				return nil, fmt.Errorf("Override %d (%s) record does not have an identity", i, o.Operation)
+			}
+
+			if o.Operation == OVERRIDE_REPLACE {
+
+				if o.ID == "" {
+					return nil, fmt.Errorf("Override %d (%s) is missing an ID", i, o.Operation)
+				}
+
+				hidden[o.ID] = true
+				delete(added, o.ID)
+			}
+
+			// A record added with the identity of an existing record
+			// takes its place rather than being returned alongside it
+
+			hidden[id] = true
+
+			_, exists := added[id]
+
+			if !exists {
+				order = append(order, id)
+			}
+
+			added[id] = o.Record
+
+		case OVERRIDE_SUPPRESS:
+
+			if o.ID == "" {
+				return nil, fmt.Errorf("Override %d (%s) is missing an ID", i, o.Operation)
+			}
+
+			hidden[o.ID] = true
+			delete(added, o.ID)
+
+		default:
+			return nil, fmt.Errorf("Override %d has an invalid operation '%s'", i, o.Operation)
+		}
+	}
+
+	records := make(map[string][]interface{})
+
+	for _, id := range order {
+
+		r, ok := added[id]
+
+		if !ok {
+			continue
+		}
+
+		// Codes may be repeated (for example an identifier that is also
+		// a concordance) so only index each record once per code
+
+		seen := make(map[string]bool)
+
+		for _, code := range codes(r) {
+
+			if seen[code] {
+				continue
+			}
+
+			seen[code] = true
+			records[code] = append(records[code], r)
+		}
+	}
+
+	l := &OverlayLookup{
+		lookup:   lookup,
+		identity: identity,
+		hidden:   hidden,
+		records:  records,
+	}
+
+	return l, nil
+}
+
+// Find will return the results of calling `Find` on the underlying lookup, less any records that have been replaced or
+// suppressed, followed by any added or replacement records matching `code` exactly.
+func (l *OverlayLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	rsp, err := l.lookup.Find(ctx, code)
+
+	if err != nil && !IsNotFound(err) {
+		return nil, err
+	}
+
+	overlay := l.records[code]
+
+	results := make([]interface{}, 0, len(rsp)+len(overlay))
+
+	for _, r := range rsp {
+
+		if l.hidden[l.identityOf(r)] {
+			continue
+		}
+
+		results = append(results, r)
+	}
+
+	results = append(results, overlay...)
+
+	if len(results) == 0 {
+		return nil, NotFound(code)
+	}
+
+	return results, nil
+}
+
+// Append will append `data` to the underlying lookup.
+func (l *OverlayLookup) Append(ctx context.Context, data interface{}) error {
+	return l.lookup.Append(ctx, data)
+}
+
+// identityOf returns the identity of `r`, unwrapping the results of `ChainLookup` instances.
+func (l *OverlayLookup) identityOf(r interface{}) string {
+
+	cr, ok := r.(*ChainResult)
+
+	if ok {
+		r = cr.Record
+	}
+
+	return l.identity(r)
+}
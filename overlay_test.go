@@ -0,0 +1,106 @@
+package aircraft_test
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"testing"
+)
+
+func TestOverlayLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	bravo := &icao.Aircraft{Designator: "B", ModelFullName: "bravo"}
+
+	base := aircrafttest.NewFakeLookup(nil,
+		&icao.Aircraft{Designator: "A", ModelFullName: "alpha"},
+		bravo,
+		&icao.Aircraft{Designator: "B", ModelFullName: "bravo two"},
+		&icao.Aircraft{Designator: "C", ModelFullName: "charlie"},
+	)
+
+	identity := func(r interface{}) string {
+		return r.(*icao.Aircraft).ModelFullName
+	}
+
+	codes := func(r interface{}) []string {
+		return []string{r.(*icao.Aircraft).Designator}
+	}
+
+	overrides := []*aircraft.Override{
+		&aircraft.Override{Operation: aircraft.OVERRIDE_REPLACE, ID: "alpha", Record: &icao.Aircraft{Designator: "A", ModelFullName: "alpha (corrected)"}},
+		&aircraft.Override{Operation: aircraft.OVERRIDE_SUPPRESS, ID: "bravo two"},
+		&aircraft.Override{Operation: aircraft.OVERRIDE_REPLACE, ID: "charlie", Record: &icao.Aircraft{Designator: "D", ModelFullName: "charlie"}},
+		&aircraft.Override{Operation: aircraft.OVERRIDE_ADD, Record: &icao.Aircraft{Designator: "E", ModelFullName: "echo"}},
+		&aircraft.Override{Operation: aircraft.OVERRIDE_ADD, Record: &icao.Aircraft{Designator: "B", ModelFullName: "bravo"}},
+	}
+
+	l, err := aircraft.NewOverlayLookupWithOverrides(ctx, base, overrides, identity, codes)
+
+	if err != nil {
+		t.Fatalf("Failed to create overlay lookup, %v", err)
+	}
+
+	tests := map[string][]string{
+		"A": []string{"alpha (corrected)"},
+		"B": []string{"bravo"},
+		"D": []string{"charlie"},
+		"E": []string{"echo"},
+	}
+
+	for code, names := range tests {
+
+		results, err := l.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Failed to find '%s', %v", code, err)
+		}
+
+		if len(results) != len(names) {
+			t.Fatalf("Expected %d results for '%s', got %d", len(names), code, len(results))
+		}
+
+		for i, r := range results {
+
+			name := r.(*icao.Aircraft).ModelFullName
+
+			if name != names[i] {
+				t.Fatalf("Expected '%s' for '%s', got '%s'", names[i], code, name)
+			}
+		}
+	}
+
+	// Bravo was added with the identity of an existing record so it is only returned once, by the overlay
+
+	results, err := l.Find(ctx, "B")
+
+	if err != nil || len(results) != 1 || results[0] == bravo {
+		t.Fatalf("Expected bravo to be returned once by the overlay, %v", results)
+	}
+
+	// Charlie was replaced by a record with a different code
+
+	_, err = l.Find(ctx, "C")
+
+	if !aircraft.IsNotFound(err) {
+		t.Fatalf("Expected not found error, got %v", err)
+	}
+
+	invalid := [][]*aircraft.Override{
+		[]*aircraft.Override{&aircraft.Override{Operation: "example", ID: "alpha"}},
+		[]*aircraft.Override{&aircraft.Override{Operation: aircraft.OVERRIDE_SUPPRESS}},
+		[]*aircraft.Override{&aircraft.Override{Operation: aircraft.OVERRIDE_REPLACE, Record: &icao.Aircraft{Designator: "A", ModelFullName: "alpha"}}},
+		[]*aircraft.Override{&aircraft.Override{Operation: aircraft.OVERRIDE_ADD}},
+	}
+
+	for i, o := range invalid {
+
+		_, err := aircraft.NewOverlayLookupWithOverrides(ctx, base, o, identity, codes)
+
+		if err == nil {
+			t.Fatalf("Expected invalid overrides %d to fail", i)
+		}
+	}
+}
@@ -10,6 +10,38 @@ type Aircraft struct {
 	SFOMuseumID    int    `json:"sfomuseum:aircraft_id"`
 	ICAODesignator string `json:"icao:designator,omitempty"`
 	WikidataID     string `json:"wd:id,omitempty"`
+	// Concordances is the complete set of `wof:concordances` for the aircraft, keyed by namespaced identifier (for example `icao:designator` or `wd:id`).
+	Concordances map[string]string `json:"wof:concordances,omitempty"`
+	// Deprecated is true if the aircraft's Who's On First record has been deprecated.
+	Deprecated bool `json:"sfomuseum:is_deprecated,omitempty"`
+	// NotCurrent is true if the aircraft's Who's On First record is known not to be current.
+	NotCurrent bool `json:"sfomuseum:is_not_current,omitempty"`
+	// SupersededBy is the list of Who's On First IDs of the records that supersede this aircraft's record.
+	SupersededBy []int64 `json:"wof:superseded_by,omitempty"`
+	// Inception is the EDTF date the aircraft entered service, derived from its `edtf:inception` property.
+	Inception string `json:"edtf:inception,omitempty"`
+	// Cessation is the EDTF date the aircraft left service, derived from its `edtf:cessation` property.
+	Cessation string `json:"edtf:cessation,omitempty"`
+	// Names is the set of preferred, variant and localized names for the aircraft, derived from its `name:*` properties and keyed
+	// by language and qualifier (for example `eng_x_preferred` or `fra_x_variant`).
+	Names map[string][]string `json:"names,omitempty"`
+	// ParentID is the Who's On First ID of the aircraft's parent (for example the family that it is a variant of), derived from its `wof:parent_id` property.
+	ParentID int64 `json:"wof:parent_id,omitempty"`
+	// BelongsTo is the list of Who's On First IDs of the aircraft's ancestors, derived from its `wof:belongsto` property.
+	BelongsTo []int64 `json:"wof:belongsto,omitempty"`
+	// Extras is the set of additional properties extracted during compilation, keyed by property path. See `CompileOptions` for details.
+	Extras map[string]interface{} `json:"extras,omitempty"`
+}
+
+// IsCurrent returns a boolean value indicating whether the aircraft's record is current, which is to say it has not been
+// deprecated, superseded or otherwise flagged as not current.
+func (a *Aircraft) IsCurrent() bool {
+	return !a.Deprecated && !a.NotCurrent && !a.IsSuperseded()
+}
+
+// IsSuperseded returns a boolean value indicating whether the aircraft's record has been superseded by one or more other records.
+func (a *Aircraft) IsSuperseded() bool {
+	return len(a.SupersededBy) > 0
 }
 
 func (a *Aircraft) String() string {
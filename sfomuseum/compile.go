@@ -1,8 +1,11 @@
 package sfomuseum
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"github.com/aaronland/go-json-query"
+	"github.com/sfomuseum/go-edtf/parser"
 	"github.com/sfomuseum/go-sfomuseum-geojson/feature"
 	"github.com/whosonfirst/go-whosonfirst-geojson-v2/properties/whosonfirst"
 	"github.com/whosonfirst/go-whosonfirst-geojson-v2/utils"
@@ -10,24 +13,73 @@ import (
 	"github.com/whosonfirst/go-whosonfirst-iterate/iterator"
 	"github.com/whosonfirst/go-whosonfirst-uri"
 	"io"
+	"sort"
 	"sync"
 )
 
+// CompileOptions defines options for compiling SFO Museum aircraft data.
+type CompileOptions struct {
+	// Properties is an optional list of additional GeoJSON properties to extract in to the `Extras` map of each `Aircraft` record.
+	Properties []*Property
+	// Include is an optional set of queries that a feature must match in order to be included in the compiled data.
+	Include *query.QuerySet
+	// Exclude is an optional set of queries that, if matched, will cause a feature to be excluded from the compiled data.
+	Exclude *query.QuerySet
+	// Lenient signals that features which fail to compile should be skipped, and recorded in the compile report, rather than
+	// causing compilation to fail.
+	Lenient bool
+	// Previous is an optional list of records produced by a previous compilation. If `PreviousState` is also defined then features
+	// which have not changed since that compilation are not reprocessed and their records are copied from this list instead.
+	Previous []*Aircraft
+	// PreviousState is the optional `CompileState` produced by a previous compilation. It is ignored if it was produced using
+	// different options.
+	PreviousState *CompileState
+	// Progress is an optional callback function invoked after each feature has been processed with the progress of the compilation
+	// so far. Calls are serialized but may happen on different goroutines.
+	Progress func(*CompileProgress)
+}
+
 // CompileAircraftData will generate a list of `Aircraft` struct to be used as the source data for an `SFOMuseumLookup` instance.
 // The list of aircraft are compiled by iterating over one or more source. `iterator_uri` is a valid `whosonfirst/go-whosonfirst-iterate` URI
 // and `iterator_sources` are one more (iterator) URIs to process.
 func CompileAircraftData(ctx context.Context, iterator_uri string, iterator_sources ...string) ([]*Aircraft, error) {
+	opts := &CompileOptions{}
+	return CompileAircraftDataWithOptions(ctx, opts, iterator_uri, iterator_sources...)
+}
+
+// CompileAircraftDataWithOptions is identical to `CompileAircraftData` but with additional options defined by `opts`. If `opts` defines
+// include or exclude query filters they are applied to each feature, after alternate geometries have been skipped. For example, an
+// include query of `properties.wof:concordances.icao:designator=.+` will only compile records that have an ICAO designator.
+// If `opts.Lenient` is true then features which fail to compile are skipped; use `CompileAircraftDataWithReport` to inspect them.
+func CompileAircraftDataWithOptions(ctx context.Context, opts *CompileOptions, iterator_uri string, iterator_sources ...string) ([]*Aircraft, error) {
+
+	lookup, _, err := CompileAircraftDataWithReport(ctx, opts, iterator_uri, iterator_sources...)
+	return lookup, err
+}
+
+// CompileAircraftDataWithReport is identical to `CompileAircraftDataWithOptions` but also returns a `CompileReport` detailing the number of
+// features that were processed, skipped or failed to compile and the `CompileState` needed to compile the same sources incrementally. If
+// `opts.Lenient` is false the report only includes the features that were iterated before the first failure. Records are sorted by the
+// path of the feature they were compiled from so that an incremental compilation produces output identical to a full compilation. If `ctx`
+// is cancelled before every feature has been processed an error wrapping the context's error is returned rather than partial data.
+func CompileAircraftDataWithReport(ctx context.Context, opts *CompileOptions, iterator_uri string, iterator_sources ...string) ([]*Aircraft, *CompileReport, error) {
+
+	compiled := make([]*compiledRecord, 0)
+	report := newCompileReport()
+	state := newCompileState(opts)
+
+	report.State = state
+
+	previous := newPreviousCompilation(opts, state.Options)
 
-	lookup := make([]*Aircraft, 0)
 	mu := new(sync.RWMutex)
 
 	iter_cb := func(ctx context.Context, fh io.ReadSeeker, args ...interface{}) error {
 
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			// pass
+		err := ctx.Err()
+
+		if err != nil {
+			return err
 		}
 
 		path, err := emitter.PathForContext(ctx)
@@ -36,71 +88,361 @@ func CompileAircraftData(ctx context.Context, iterator_uri string, iterator_sour
 			return fmt.Errorf("Failed to derive path from context, %w", err)
 		}
 
-		_, uri_args, err := uri.ParseURI(path)
+		a, feature_state, reused, err := previous.compile(ctx, opts, path, fh)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if opts.Progress != nil {
+
+			defer func() {
+				opts.Progress(report.Progress())
+			}()
+		}
 
 		if err != nil {
-			return fmt.Errorf("Failed to parse %s, %w", path, err)
+
+			failure := &CompileFailure{
+				Path: path,
+				Err:  err,
+			}
+
+			report.fail(failure)
+
+			if opts.Lenient {
+				return nil
+			}
+
+			return failure
 		}
 
-		if uri_args.IsAlternate {
+		state.Features[path] = feature_state
+
+		if a == nil {
+			report.skip()
 			return nil
 		}
 
-		f, err := feature.LoadFeatureFromReader(fh)
+		report.process()
 
-		if err != nil {
-			return fmt.Errorf("Failed load feature from %s, %w", path, err)
+		if reused {
+			report.reuse()
 		}
 
-		// TO DO: https://github.com/sfomuseum/go-sfomuseum-aircraft-tools/issues/1
+		r := &compiledRecord{
+			path:     path,
+			aircraft: a,
+		}
 
-		wof_id := whosonfirst.Id(f)
-		name := whosonfirst.Name(f)
+		compiled = append(compiled, r)
+		return nil
+	}
 
-		sfom_id := utils.Int64Property(f.Bytes(), []string{"properties.sfomuseum:aircraft_id"}, -1)
+	iter, err := iterator.NewIterator(ctx, iterator_uri, iter_cb)
 
-		concordances, err := whosonfirst.Concordances(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to create iterator, %w", err)
+	}
 
-		if err != nil {
-			return err
-		}
+	err = iter.IterateURIs(ctx, iterator_sources...)
 
-		a := &Aircraft{
-			WOFID:       wof_id,
-			SFOMuseumID: int(sfom_id),
-			Name:        name,
-		}
+	if err != nil {
+		return nil, report, fmt.Errorf("Failed to iterate sources, %w", err)
+	}
 
-		code, ok := concordances["icao:designator"]
+	// Features that are emitted after the context has been cancelled are not
+	// processed so ensure that partial data is never returned
 
-		if ok {
-			a.ICAODesignator = code
-		}
+	err = ctx.Err()
 
-		id, ok := concordances["wd:id"]
+	if err != nil {
+		return nil, report, fmt.Errorf("Compilation was cancelled, %w", err)
+	}
+
+	sort.Slice(compiled, func(i int, j int) bool {
+		return compiled[i].path < compiled[j].path
+	})
 
-		if ok {
-			a.WikidataID = id
+	lookup := make([]*Aircraft, len(compiled))
+
+	for i, r := range compiled {
+		lookup[i] = r.aircraft
+	}
+
+	return lookup, report, nil
+}
+
+// compile derives an `Aircraft` record, and its state, from the GeoJSON feature stored at `path` in `fh`. If the feature has not changed
+// since the previous compilation the record, and state, from that compilation are returned instead and the boolean value is true. The
+// record is nil (and there is no error) if the feature was skipped. See `compileFeature` for details.
+func (p *previousCompilation) compile(ctx context.Context, opts *CompileOptions, path string, fh io.ReadSeeker) (*Aircraft, *FeatureState, bool, error) {
+
+	body, err := io.ReadAll(fh)
+
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("Failed to read feature, %w", err)
+	}
+
+	hash := hashFeature(body)
+
+	a, fs := p.find(path, hash)
+
+	if fs != nil {
+
+		// Previous records have been decoded from JSON so normalize their extras
+		// so that they are identical to those of freshly compiled records
+
+		if a != nil && a.Extras != nil {
+
+			extras, err := normalizeExtras(opts.Properties, a.Extras)
+
+			if err != nil {
+				return nil, nil, false, err
+			}
+
+			reused := *a
+			reused.Extras = extras
+			a = &reused
 		}
 
-		mu.Lock()
-		lookup = append(lookup, a)
-		mu.Unlock()
+		return a, fs, true, nil
+	}
 
-		return nil
+	a, err = compileFeature(ctx, opts, path, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, nil, false, err
 	}
 
-	iter, err := iterator.NewIterator(ctx, iterator_uri, iter_cb)
+	fs = &FeatureState{
+		Hash:         hash,
+		LastModified: utils.Int64Property(body, []string{"properties.wof:lastmodified"}, -1),
+	}
+
+	if a != nil {
+		fs.WOFID = a.WOFID
+	}
+
+	return a, fs, false, nil
+}
+
+// compiledRecord is an `Aircraft` record and the path of the feature it was compiled from.
+type compiledRecord struct {
+	path     string
+	aircraft *Aircraft
+}
+
+// previousCompilation is the set of records, and the state, produced by a previous compilation.
+type previousCompilation struct {
+	state   *CompileState
+	records map[int64]*Aircraft
+}
+
+// newPreviousCompilation returns the previous compilation defined by `opts`. If `opts` does not define a previous state, or that state
+// was produced using different options (as identified by `fingerprint`) then the previous compilation is empty.
+func newPreviousCompilation(opts *CompileOptions, fingerprint string) *previousCompilation {
+
+	p := &previousCompilation{
+		records: make(map[int64]*Aircraft),
+	}
+
+	if opts.PreviousState == nil || opts.PreviousState.Options != fingerprint {
+		return p
+	}
+
+	p.state = opts.PreviousState
+
+	for _, a := range opts.Previous {
+		p.records[a.WOFID] = a
+	}
+
+	return p
+}
+
+// find returns the record, and the state, from the previous compilation for the feature at `path` if its hash is still `hash`. If the
+// feature was skipped by the previous compilation the record is nil. If the feature has changed, or its record is missing, the state is nil.
+func (p *previousCompilation) find(path string, hash string) (*Aircraft, *FeatureState) {
+
+	if p.state == nil {
+		return nil, nil
+	}
+
+	fs, ok := p.state.Features[path]
+
+	if !ok || fs.Hash != hash {
+		return nil, nil
+	}
+
+	if fs.WOFID == 0 {
+		return nil, fs
+	}
+
+	a, ok := p.records[fs.WOFID]
+
+	if !ok {
+		return nil, nil
+	}
+
+	return a, fs
+}
+
+// compileFeature derives an `Aircraft` record from the GeoJSON feature, stored at `path`, in `fh`. It returns nil (and no error) if the
+// feature is an alternate geometry or does not match the query filters defined in `opts`.
+func compileFeature(ctx context.Context, opts *CompileOptions, path string, fh io.ReadSeeker) (*Aircraft, error) {
+
+	_, uri_args, err := uri.ParseURI(path)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create iterator, %w", err)
+		return nil, fmt.Errorf("Failed to parse path, %w", err)
 	}
 
-	err = iter.IterateURIs(ctx, iterator_sources...)
+	if uri_args.IsAlternate {
+		return nil, nil
+	}
+
+	f, err := feature.LoadFeatureFromReader(fh)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed load feature, %w", err)
+	}
+
+	ok, err := includeFeature(ctx, opts, f.Bytes())
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to apply query filters, %w", err)
+	}
+
+	if !ok {
+		return nil, nil
+	}
+
+	// Properties beyond the ones below can be extracted using CompileOptions.Properties
+	// https://github.com/sfomuseum/go-sfomuseum-aircraft-tools/issues/1
+
+	wof_id := whosonfirst.Id(f)
+	name := whosonfirst.Name(f)
+
+	sfom_id := utils.Int64Property(f.Bytes(), []string{"properties.sfomuseum:aircraft_id"}, -1)
+
+	concordances, err := whosonfirst.Concordances(f)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive concordances, %w", err)
+	}
+
+	a := &Aircraft{
+		WOFID:       wof_id,
+		SFOMuseumID: int(sfom_id),
+		Name:        name,
+	}
+
+	if len(concordances) > 0 {
+		a.Concordances = concordances
+	}
+
+	code, ok := concordances["icao:designator"]
+
+	if ok {
+		a.ICAODesignator = code
+	}
+
+	id, ok := concordances["wd:id"]
+
+	if ok {
+		a.WikidataID = id
+	}
+
+	is_deprecated, err := whosonfirst.IsDeprecated(f)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to determine whether feature is deprecated, %w", err)
+	}
+
+	a.Deprecated = is_deprecated.IsKnown() && is_deprecated.IsTrue()
+
+	// Only an explicit "mz:is_current" property marks a record as not current. whosonfirst.IsCurrent
+	// also treats a known cessation date as not current which would exclude every retired aircraft
+	// type from lookups. Deprecation and supersession are recorded separately.
+
+	is_current := utils.Int64Property(f.Bytes(), []string{"properties.mz:is_current"}, -1)
+	a.NotCurrent = is_current == 0
+
+	superseded_by := whosonfirst.SupersededBy(f)
+
+	if len(superseded_by) > 0 {
+		a.SupersededBy = superseded_by
+	}
+
+	inception := whosonfirst.Inception(f)
+	cessation := whosonfirst.Cessation(f)
+
+	for _, d := range []string{inception, cessation} {
+
+		if !isUnknownDate(d) && !parser.IsValid(d) {
+			return nil, fmt.Errorf("Invalid EDTF date '%s'", d)
+		}
+	}
+
+	if !isUnknownDate(inception) {
+		a.Inception = inception
+	}
+
+	if !isUnknownDate(cessation) {
+		a.Cessation = cessation
+	}
+
+	names := whosonfirst.Names(f)
+
+	if len(names) > 0 {
+		a.Names = names
+	}
+
+	parent_id := whosonfirst.ParentId(f)
+
+	if parent_id > 0 {
+		a.ParentID = parent_id
+	}
+
+	belongsto := make([]int64, 0)
+
+	for _, id := range whosonfirst.BelongsTo(f) {
+
+		if id > 0 {
+			belongsto = append(belongsto, id)
+		}
+	}
+
+	if len(belongsto) > 0 {
+		a.BelongsTo = belongsto
+	}
+
+	for _, p := range opts.Properties {
+
+		v, ok, err := p.Extract(f.Bytes())
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to extract property, %w", err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		if a.Extras == nil {
+			a.Extras = make(map[string]interface{})
+		}
+
+		a.Extras[p.Path] = v
+	}
+
+	// Normalize extras so that they are identical to those of records reused from a previous compilation
+
+	extras, err := normalizeExtras(opts.Properties, a.Extras)
 
 	if err != nil {
-		return nil, fmt.Errorf("Failed to iterate sources, %w", err)
+		return nil, err
 	}
 
-	return lookup, nil
+	a.Extras = extras
+	return a, nil
 }
@@ -0,0 +1,671 @@
+package sfomuseum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aaronland/go-json-query"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// compile_fixtures are minimal SFO Museum aircraft features, keyed by filename, used to test compiling data.
+var compile_fixtures = map[string]string{
+	"9990001001.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001001, "wof:name": "Example 747-400", "wof:placetype": "custom", "wof:repo": "sfomuseum-data-aircraft", "sfomuseum:placetype": "aircraft", "sfomuseum:aircraft_id": 9001, "wof:concordances": {"icao:designator": "B744", "wd:id": "Q5473"}, "edtf:inception": "1988", "edtf:cessation": "..", "name:fra_x_preferred": ["Exemple 747-400"], "wof:parent_id": 9990001003, "wof:belongsto": [9990001003], "sfomuseum:manufacturer": "Boeing", "wof:lastmodified": 1600000000, "mz:is_current": 1}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+	"9990001002.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001002, "wof:name": "Example deprecated", "wof:placetype": "custom", "wof:repo": "sfomuseum-data-aircraft", "sfomuseum:placetype": "aircraft", "sfomuseum:aircraft_id": 9002, "wof:concordances": {}, "edtf:deprecated": "2020-01-01", "wof:superseded_by": [9990001001], "wof:lastmodified": 1600000000}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+	"9990001003.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001003, "wof:name": "Example 747", "wof:placetype": "custom", "wof:repo": "sfomuseum-data-aircraft", "sfomuseum:placetype": "aircraft", "sfomuseum:aircraft_id": 9003, "wof:concordances": {"wd:id": "Q5473"}, "wof:lastmodified": 1500000000}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+}
+
+// writeCompileFixtures writes `fixtures` to a new temporary directory and returns its path.
+func writeCompileFixtures(t *testing.T, fixtures map[string]string) string {
+
+	t.Helper()
+
+	root := t.TempDir()
+
+	for fname, body := range fixtures {
+
+		err := os.WriteFile(filepath.Join(root, fname), []byte(body), 0644)
+
+		if err != nil {
+			t.Fatalf("Failed to write %s, %v", fname, err)
+		}
+	}
+
+	return root
+}
+
+// sortAircraft sorts `aircraft_list` by Who's On First ID. Compiled data is sorted by the path of the feature each record was compiled
+// from which does not necessarily follow Who's On First ID.
+func sortAircraft(aircraft_list []*Aircraft) {
+
+	sort.Slice(aircraft_list, func(i int, j int) bool {
+		return aircraft_list[i].WOFID < aircraft_list[j].WOFID
+	})
+}
+
+func TestCompileAircraftData(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := writeCompileFixtures(t, compile_fixtures)
+
+	aircraft_list, err := CompileAircraftData(ctx, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	if len(aircraft_list) != 3 {
+		t.Fatalf("Expected 3 aircraft but got %d", len(aircraft_list))
+	}
+
+	sortAircraft(aircraft_list)
+
+	a := aircraft_list[0]
+
+	if a.ICAODesignator != "B744" || a.WikidataID != "Q5473" || a.SFOMuseumID != 9001 {
+		t.Fatalf("Invalid identifiers for %s", a)
+	}
+
+	if a.Inception != "1988" || a.Cessation != "" {
+		t.Fatalf("Invalid dates for %s, '%s' '%s'", a, a.Inception, a.Cessation)
+	}
+
+	if a.DisplayName("fra") != "Exemple 747-400" {
+		t.Fatalf("Invalid display name for %s, '%s'", a, a.DisplayName("fra"))
+	}
+
+	if a.ParentID != 9990001003 || len(a.BelongsTo) != 1 {
+		t.Fatalf("Invalid hierarchy for %s", a)
+	}
+
+	if !a.IsCurrent() {
+		t.Fatalf("Expected %s to be current", a)
+	}
+
+	if a.Extras != nil {
+		t.Fatalf("Expected %s to have no extras", a)
+	}
+
+	deprecated := aircraft_list[1]
+
+	if !deprecated.Deprecated || !deprecated.IsSuperseded() || deprecated.IsCurrent() {
+		t.Fatalf("Expected %s to be deprecated and superseded", deprecated)
+	}
+}
+
+func TestCompileAircraftDataCeased(t *testing.T) {
+
+	ctx := context.Background()
+
+	fixtures := map[string]string{
+		"9990001011.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001011, "wof:name": "Example retired", "wof:placetype": "custom", "wof:repo": "sfomuseum-data-aircraft", "sfomuseum:placetype": "aircraft", "sfomuseum:aircraft_id": 9011, "wof:concordances": {"icao:designator": "ZR41"}, "edtf:inception": "1969", "edtf:cessation": "1979", "wof:lastmodified": 1600000000}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+		"9990001012.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001012, "wof:name": "Example not current", "wof:placetype": "custom", "wof:repo": "sfomuseum-data-aircraft", "sfomuseum:placetype": "aircraft", "sfomuseum:aircraft_id": 9012, "wof:concordances": {"icao:designator": "ZN41"}, "mz:is_current": 0, "wof:lastmodified": 1600000000}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+	}
+
+	root := writeCompileFixtures(t, fixtures)
+
+	aircraft_list, err := CompileAircraftData(ctx, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	if len(aircraft_list) != 2 {
+		t.Fatalf("Expected 2 aircraft but got %d", len(aircraft_list))
+	}
+
+	sortAircraft(aircraft_list)
+
+	// A cessation date alone does not make a record not current
+
+	retired := aircraft_list[0]
+
+	if retired.Cessation != "1979" || retired.NotCurrent || !retired.IsCurrent() {
+		t.Fatalf("Expected %s to have ceased but still be current", retired)
+	}
+
+	not_current := aircraft_list[1]
+
+	if !not_current.NotCurrent || not_current.IsCurrent() {
+		t.Fatalf("Expected %s to not be current", not_current)
+	}
+
+	lu := newTestLookup(t, ctx, aircraft_list...)
+
+	results, err := lu.Find(ctx, "ZR41")
+
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected to find retired aircraft, %v", err)
+	}
+
+	on, _ := time.Parse("2006-01-02", "1975-06-01")
+
+	in_service, err := lu.FindInServiceOn(ctx, on)
+
+	if err != nil {
+		t.Fatalf("Failed to find aircraft in service, %v", err)
+	}
+
+	if len(in_service) != 1 || in_service[0].WOFID != retired.WOFID {
+		t.Fatalf("Expected %s to be in service on %v", retired, on)
+	}
+
+	_, err = lu.Find(ctx, "ZN41")
+
+	if err == nil {
+		t.Fatalf("Expected not current aircraft to not be found")
+	}
+}
+
+func TestCompileAircraftDataWithProperties(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := writeCompileFixtures(t, compile_fixtures)
+
+	properties := make([]*Property, 0)
+
+	for _, str := range []string{"sfomuseum:manufacturer", "properties.wof:lastmodified=int", "wof:concordances=json"} {
+
+		p, err := NewProperty(str)
+
+		if err != nil {
+			t.Fatalf("Failed to create property for '%s', %v", str, err)
+		}
+
+		properties = append(properties, p)
+	}
+
+	opts := &CompileOptions{
+		Properties: properties,
+	}
+
+	aircraft_list, err := CompileAircraftDataWithOptions(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	sortAircraft(aircraft_list)
+
+	a := aircraft_list[0]
+
+	manufacturer, ok := a.ExtraString("sfomuseum:manufacturer")
+
+	if !ok || manufacturer != "Boeing" {
+		t.Fatalf("Invalid manufacturer for %s, '%s'", a, manufacturer)
+	}
+
+	lastmod, ok := a.ExtraInt("wof:lastmodified")
+
+	if !ok || lastmod != 1600000000 {
+		t.Fatalf("Invalid lastmodified for %s, %d", a, lastmod)
+	}
+
+	_, ok = a.Extras["wof:concordances"]
+
+	if !ok {
+		t.Fatalf("Expected %s to have concordances in its extras", a)
+	}
+
+	_, ok = aircraft_list[1].ExtraString("sfomuseum:manufacturer")
+
+	if ok {
+		t.Fatalf("Expected %s to have no manufacturer", aircraft_list[1])
+	}
+
+	bad, _ := NewProperty("wof:name=int")
+	opts.Properties = []*Property{bad}
+
+	_, err = CompileAircraftDataWithOptions(ctx, opts, "directory://", root)
+
+	if err == nil {
+		t.Fatalf("Expected extracting a string as an integer to fail")
+	}
+}
+
+func TestNewProperty(t *testing.T) {
+
+	valid := map[string]string{
+		"sfomuseum:manufacturer":          "sfomuseum:manufacturer=string",
+		"wof:lastmodified=int":            "wof:lastmodified=int",
+		"properties.wof:belongsto=json":   "wof:belongsto=json",
+		"sfomuseum:is_demo=bool":          "sfomuseum:is_demo=bool",
+		"sfomuseum:wingspan_meters=float": "sfomuseum:wingspan_meters=float",
+	}
+
+	for str, expected := range valid {
+
+		p, err := NewProperty(str)
+
+		if err != nil {
+			t.Fatalf("Failed to create property for '%s', %v", str, err)
+		}
+
+		if p.String() != expected {
+			t.Fatalf("Invalid property for '%s', expected '%s' but got '%s'", str, expected, p.String())
+		}
+	}
+
+	for _, str := range []string{"", "=int", "wof:name=date"} {
+
+		_, err := NewProperty(str)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to be an invalid property", str)
+		}
+	}
+}
+
+func TestCompileAircraftDataWithFilters(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := writeCompileFixtures(t, compile_fixtures)
+
+	include, err := NewQuerySet("", "properties.wof:concordances.wd:id=^Q5473$")
+
+	if err != nil {
+		t.Fatalf("Failed to create include query set, %v", err)
+	}
+
+	exclude, err := NewQuerySet(query.QUERYSET_MODE_ANY, "properties.wof:concordances.icao:designator=.+", "properties.wof:name=deprecated")
+
+	if err != nil {
+		t.Fatalf("Failed to create exclude query set, %v", err)
+	}
+
+	opts := &CompileOptions{
+		Include: include,
+	}
+
+	aircraft_list, err := CompileAircraftDataWithOptions(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	if len(aircraft_list) != 2 {
+		t.Fatalf("Expected 2 included aircraft but got %d", len(aircraft_list))
+	}
+
+	opts.Exclude = exclude
+
+	aircraft_list, err = CompileAircraftDataWithOptions(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	if len(aircraft_list) != 1 || aircraft_list[0].WOFID != 9990001003 {
+		t.Fatalf("Expected only 9990001003 to be included")
+	}
+
+	// Query filters passed to the iterator URI use the same default modes as the build-sfomuseum-data tool
+
+	q := url.Values{}
+	q.Set("uri", "directory://")
+	q.Set("source", root)
+	q["exclude"] = []string{"properties.wof:concordances.icao:designator=.+", "properties.wof:name=deprecated"}
+
+	filter_opts := &CompileOptions{}
+
+	err = setQueryFilters(filter_opts, q)
+
+	if err != nil {
+		t.Fatalf("Failed to set query filters, %v", err)
+	}
+
+	if filter_opts.Exclude.Mode != DEFAULT_EXCLUDE_MODE {
+		t.Fatalf("Expected default exclude mode to be %s, got %s", DEFAULT_EXCLUDE_MODE, filter_opts.Exclude.Mode)
+	}
+
+	lu, err := NewLookup(ctx, "sfomuseum://iterator?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = lu.Find(ctx, "wof:9990001001")
+
+	if err == nil {
+		t.Fatalf("Expected 9990001001 to be excluded")
+	}
+
+	_, err = lu.Find(ctx, "wof:9990001003")
+
+	if err != nil {
+		t.Fatalf("Expected 9990001003 to be included, %v", err)
+	}
+
+	_, err = NewQuerySet("SOME", "wof:name=.*")
+
+	if err == nil {
+		t.Fatalf("Expected invalid query mode to fail")
+	}
+
+	_, err = NewQuerySet("", "wof:name")
+
+	if err == nil {
+		t.Fatalf("Expected invalid query to fail")
+	}
+}
+
+func TestCompileAircraftDataLenient(t *testing.T) {
+
+	ctx := context.Background()
+
+	fixtures := make(map[string]string)
+
+	for fname, body := range compile_fixtures {
+		fixtures[fname] = body
+	}
+
+	fixtures["9990001004.geojson"] = `{"type": "Feature", "properties": {`
+	fixtures["9990001005.geojson"] = `{"type": "Feature", "properties": {"wof:id": 9990001005, "wof:name": "Example bad date", "edtf:inception": "not a date"}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`
+	fixtures["9990001001-alt-example.geojson"] = compile_fixtures["9990001001.geojson"]
+
+	root := writeCompileFixtures(t, fixtures)
+
+	opts := &CompileOptions{}
+
+	_, err := CompileAircraftDataWithOptions(ctx, opts, "directory://", root)
+
+	if err == nil {
+		t.Fatalf("Expected strict compilation to fail")
+	}
+
+	opts.Lenient = true
+
+	aircraft_list, report, err := CompileAircraftDataWithReport(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	if len(aircraft_list) != 3 {
+		t.Fatalf("Expected 3 aircraft but got %d", len(aircraft_list))
+	}
+
+	if report.Processed != 3 || report.Skipped != 1 || report.Failed != 2 {
+		t.Fatalf("Invalid report, %s", report)
+	}
+
+	if len(report.Failures) != 2 || filepath.Base(report.Failures[0].Path) != "9990001004.geojson" || filepath.Base(report.Failures[1].Path) != "9990001005.geojson" {
+		t.Fatalf("Invalid failures, %v", report.Err())
+	}
+
+	var compile_errors CompileErrors
+
+	if !errors.As(report.Err(), &compile_errors) || len(compile_errors) != 2 {
+		t.Fatalf("Expected report error to be a CompileErrors with 2 failures, %v", report.Err())
+	}
+}
+
+func TestCompileAircraftDataIncremental(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := writeCompileFixtures(t, compile_fixtures)
+
+	opts := &CompileOptions{}
+
+	previous, report, err := CompileAircraftDataWithReport(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	// Round-trip the state and the compiled data the same way build-sfomuseum-data does
+
+	var state_buf bytes.Buffer
+
+	err = report.State.Write(&state_buf)
+
+	if err != nil {
+		t.Fatalf("Failed to write state, %v", err)
+	}
+
+	previous_state, err := ReadCompileState(&state_buf)
+
+	if err != nil {
+		t.Fatalf("Failed to read state, %v", err)
+	}
+
+	previous_enc, err := json.Marshal(previous)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal previous data, %v", err)
+	}
+
+	previous, err = ReadAircraftData(ctx, bytes.NewReader(previous_enc))
+
+	if err != nil {
+		t.Fatalf("Failed to read previous data, %v", err)
+	}
+
+	// Change one feature, add another and remove a third
+
+	changes := map[string]string{
+		"9990001003.geojson": strings.Replace(compile_fixtures["9990001003.geojson"], "Example 747", "Example 747 (changed)", 1),
+		"9990001006.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001006, "wof:name": "Example new", "sfomuseum:aircraft_id": 9006, "wof:concordances": {"icao:designator": "B748"}}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+	}
+
+	for fname, body := range changes {
+
+		err := os.WriteFile(filepath.Join(root, fname), []byte(body), 0644)
+
+		if err != nil {
+			t.Fatalf("Failed to write %s, %v", fname, err)
+		}
+	}
+
+	err = os.Remove(filepath.Join(root, "9990001002.geojson"))
+
+	if err != nil {
+		t.Fatalf("Failed to remove feature, %v", err)
+	}
+
+	full, err := CompileAircraftData(ctx, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	incremental_opts := &CompileOptions{
+		Previous:      previous,
+		PreviousState: previous_state,
+	}
+
+	incremental, report, err := CompileAircraftDataWithReport(ctx, incremental_opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data incrementally, %v", err)
+	}
+
+	if report.Processed != 3 || report.Reused != 1 {
+		t.Fatalf("Invalid report for incremental compilation, %s", report)
+	}
+
+	full_enc, _ := json.Marshal(full)
+	incremental_enc, _ := json.Marshal(incremental)
+
+	if !bytes.Equal(full_enc, incremental_enc) {
+		t.Fatalf("Incremental compilation differs from full compilation:\n%s\n%s", full_enc, incremental_enc)
+	}
+
+	// State produced with different options should be ignored
+
+	p, _ := NewProperty("wof:lastmodified=int")
+
+	incremental_opts.Properties = []*Property{p}
+
+	_, report, err = CompileAircraftDataWithReport(ctx, incremental_opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data incrementally, %v", err)
+	}
+
+	if report.Reused != 0 {
+		t.Fatalf("Expected state compiled with different options to be ignored, %s", report)
+	}
+
+	// State produced by a different version of the compiler should be ignored
+
+	previous_state.Options = "version:0"
+	incremental_opts.Properties = nil
+
+	_, report, err = CompileAircraftDataWithReport(ctx, incremental_opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data incrementally, %v", err)
+	}
+
+	if report.Reused != 0 {
+		t.Fatalf("Expected state compiled by a different version to be ignored, %s", report)
+	}
+}
+
+func TestCompileAircraftDataIncrementalExtras(t *testing.T) {
+
+	ctx := context.Background()
+
+	fixtures := map[string]string{
+		"9990001021.geojson": `{"type": "Feature", "properties": {"wof:id": 9990001021, "wof:name": "Example extras", "sfomuseum:aircraft_id": 9021, "wof:concordances": {"wd:id": "Q5473", "icao:designator": "B744"}, "sfomuseum:count": 12}, "geometry": {"type": "Point", "coordinates": [-122.386, 37.616]}}`,
+	}
+
+	root := writeCompileFixtures(t, fixtures)
+
+	properties := make([]*Property, 0)
+
+	for _, str := range []string{"wof:concordances=json", "sfomuseum:count=int"} {
+
+		p, err := NewProperty(str)
+
+		if err != nil {
+			t.Fatalf("Failed to create property, %v", err)
+		}
+
+		properties = append(properties, p)
+	}
+
+	opts := &CompileOptions{
+		Properties: properties,
+	}
+
+	full, report, err := CompileAircraftDataWithReport(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	previous_enc, err := json.Marshal(full)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal previous data, %v", err)
+	}
+
+	previous, err := ReadAircraftData(ctx, bytes.NewReader(previous_enc))
+
+	if err != nil {
+		t.Fatalf("Failed to read previous data, %v", err)
+	}
+
+	incremental_opts := &CompileOptions{
+		Properties:    properties,
+		Previous:      previous,
+		PreviousState: report.State,
+	}
+
+	incremental, report, err := CompileAircraftDataWithReport(ctx, incremental_opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data incrementally, %v", err)
+	}
+
+	if report.Reused != 1 {
+		t.Fatalf("Expected record to be reused, %s", report)
+	}
+
+	if !reflect.DeepEqual(full[0].Extras, incremental[0].Extras) {
+		t.Fatalf("Incremental extras differ from full extras: %#v %#v", full[0].Extras, incremental[0].Extras)
+	}
+
+	incremental_enc, _ := json.Marshal(incremental)
+
+	if !bytes.Equal(previous_enc, incremental_enc) {
+		t.Fatalf("Incremental compilation differs from full compilation:\n%s\n%s", previous_enc, incremental_enc)
+	}
+}
+
+func TestCompileAircraftDataProgress(t *testing.T) {
+
+	ctx := context.Background()
+
+	root := writeCompileFixtures(t, compile_fixtures)
+
+	updates := make([]*CompileProgress, 0)
+
+	opts := &CompileOptions{
+		Progress: func(p *CompileProgress) {
+			updates = append(updates, p)
+		},
+	}
+
+	_, err := CompileAircraftDataWithOptions(ctx, opts, "directory://", root)
+
+	if err != nil {
+		t.Fatalf("Failed to compile aircraft data, %v", err)
+	}
+
+	if len(updates) != 3 {
+		t.Fatalf("Expected 3 progress updates but got %d", len(updates))
+	}
+
+	last := updates[len(updates)-1]
+
+	if last.Seen != 3 || last.Emitted != 3 || last.Failed != 0 {
+		t.Fatalf("Invalid final progress, %s", last)
+	}
+}
+
+func TestCompileAircraftDataCancelled(t *testing.T) {
+
+	root := writeCompileFixtures(t, compile_fixtures)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	opts := &CompileOptions{
+		Progress: func(p *CompileProgress) {
+			cancel()
+		},
+	}
+
+	aircraft_list, err := CompileAircraftDataWithOptions(ctx, opts, "directory://?_max_procs=1", root)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected compilation to be cancelled, %v", err)
+	}
+
+	if aircraft_list != nil {
+		t.Fatalf("Expected no data to be returned when compilation is cancelled")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+
+	_, err = CompileAircraftData(ctx, "directory://", root)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected compilation with a cancelled context to fail, %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package sfomuseum_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"testing"
+)
+
+func TestSFOMuseumLookupConformance(t *testing.T) {
+
+	opts := &aircrafttest.ConformanceOptions{
+		NewLookup: func(ctx context.Context) (aircraft.Lookup, error) {
+
+			// Use a lookup with its own lookup table so that appended records
+			// do not leak in to other tests
+
+			aircraft_list, err := sfomuseum.LoadEmbeddedData(ctx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return sfomuseum.NewLookupWithLookupFunc(ctx, sfomuseum.NewLookupFuncWithAircraft(ctx, aircraft_list))
+		},
+		Codes:   []string{"B744", "wof:1159289391", "sfomuseum:17", "wd:id:Q139289"},
+		Missing: []string{"XXXX", "wof:0", "sfomuseum:-1"},
+		NewRecord: func(i int) (string, interface{}) {
+			code := fmt.Sprintf("ZS%02d", i)
+			return code, &sfomuseum.Aircraft{WOFID: int64(9990002000 + i), Name: code, SFOMuseumID: -1, ICAODesignator: code}
+		},
+	}
+
+	aircrafttest.RunConformance(t, opts)
+}
+
+func TestLookupFuncOutsidePackage(t *testing.T) {
+
+	ctx := context.Background()
+
+	lookup_func := func(ctx context.Context) (*sfomuseum.LookupTable, error) {
+
+		table := sfomuseum.NewLookupTable()
+
+		err := table.Append(ctx, &sfomuseum.Aircraft{WOFID: 9990000101, Name: "Example aircraft", ICAODesignator: "ZX01"})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return table, nil
+	}
+
+	lu, err := sfomuseum.NewLookupWithLookupFunc(ctx, lookup_func)
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	results, err := lu.Find(ctx, "designator:ZX01")
+
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Failed to find record appended by lookup func, %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package sfomuseum
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-edtf"
+	"github.com/sfomuseum/go-edtf/parser"
+	"time"
+)
+
+// isUnknownDate returns a boolean value indicating whether `edtf_str` is an empty, unknown or open-ended EDTF string.
+func isUnknownDate(edtf_str string) bool {
+
+	switch edtf_str {
+	case edtf.UNKNOWN, edtf.UNKNOWN_2012, edtf.OPEN, edtf.OPEN_2012:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseDate parses `edtf_str` as an EDTF date. It returns nil (and no error) if `edtf_str` is empty, unknown or open-ended.
+func parseDate(edtf_str string) (*edtf.EDTFDate, error) {
+
+	if isUnknownDate(edtf_str) {
+		return nil, nil
+	}
+
+	return parser.ParseString(edtf_str)
+}
+
+// InceptionDate returns the aircraft's `edtf:inception` property parsed as an EDTF date. It returns nil (and no error) if the
+// inception date is unknown.
+func (a *Aircraft) InceptionDate() (*edtf.EDTFDate, error) {
+	return parseDate(a.Inception)
+}
+
+// CessationDate returns the aircraft's `edtf:cessation` property parsed as an EDTF date. It returns nil (and no error) if the
+// cessation date is unknown or open-ended.
+func (a *Aircraft) CessationDate() (*edtf.EDTFDate, error) {
+	return parseDate(a.Cessation)
+}
+
+// ServiceRange returns the earliest possible time the aircraft entered service, derived from the lower bound of its inception date,
+// and the latest possible time it left service, derived from the upper bound of its cessation date. Either value will be nil if it
+// is not known, or is open-ended.
+func (a *Aircraft) ServiceRange() (*time.Time, *time.Time, error) {
+
+	var lower *time.Time
+	var upper *time.Time
+
+	inception, err := a.InceptionDate()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse inception date for %s, %w", a, err)
+	}
+
+	if inception != nil && inception.Start.Lower.Timestamp != nil {
+		lower = inception.Start.Lower.Timestamp.Time()
+	}
+
+	cessation, err := a.CessationDate()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to parse cessation date for %s, %w", a, err)
+	}
+
+	if cessation != nil && cessation.End.Upper.Timestamp != nil {
+		upper = cessation.End.Upper.Timestamp.Time()
+	}
+
+	return lower, upper, nil
+}
+
+// InServiceDuring returns a boolean value indicating whether the aircraft was in service at any point between `start` and `end`
+// (inclusive). An unknown or open-ended inception or cessation date is treated as unbounded but aircraft with neither date known are
+// never considered to be in service.
+func (a *Aircraft) InServiceDuring(start time.Time, end time.Time) (bool, error) {
+
+	lower, upper, err := a.ServiceRange()
+
+	if err != nil {
+		return false, err
+	}
+
+	if lower == nil && upper == nil {
+		return false, nil
+	}
+
+	if lower != nil && lower.After(end) {
+		return false, nil
+	}
+
+	if upper != nil && upper.Before(start) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// InServiceOn returns a boolean value indicating whether the aircraft was in service on `t`. See `InServiceDuring` for details.
+func (a *Aircraft) InServiceOn(t time.Time) (bool, error) {
+	return a.InServiceDuring(t, t)
+}
+
+// FindInServiceOn will return the list of aircraft that were in service on `t`. Records that are not current are excluded unless the
+// lookup was created with the `?include_noncurrent=true` parameter.
+func (l *SFOMuseumLookup) FindInServiceOn(ctx context.Context, t time.Time) ([]*Aircraft, error) {
+	return l.FindInServiceDuring(ctx, t, t)
+}
+
+// FindInServiceDuring will return the list of aircraft that were in service at any point between `start` and `end` (inclusive).
+// Records that are not current are excluded unless the lookup was created with the `?include_noncurrent=true` parameter.
+func (l *SFOMuseumLookup) FindInServiceDuring(ctx context.Context, start time.Time, end time.Time) ([]*Aircraft, error) {
+
+	if end.Before(start) {
+		return nil, fmt.Errorf("Invalid date range, %v is before %v", end, start)
+	}
+
+	results := make([]*Aircraft, 0)
+	var iter_err error
+
+	l.table.each(func(a *Aircraft) bool {
+
+		err := ctx.Err()
+
+		if err != nil {
+			iter_err = err
+			return false
+		}
+
+		if !l.include_noncurrent && !a.IsCurrent() {
+			return true
+		}
+
+		ok, err := a.InServiceDuring(start, end)
+
+		if err != nil {
+			iter_err = err
+			return false
+		}
+
+		if ok {
+			results = append(results, a)
+		}
+
+		return true
+	})
+
+	if iter_err != nil {
+		return nil, iter_err
+	}
+
+	return results, nil
+}
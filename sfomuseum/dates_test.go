@@ -0,0 +1,142 @@
+package sfomuseum
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAircraftInServiceDuring(t *testing.T) {
+
+	a := &Aircraft{
+		WOFID:     9990000044,
+		Name:      "Dated aircraft",
+		Inception: "1968~",
+		Cessation: "1995-06",
+	}
+
+	tests := []struct {
+		start    string
+		end      string
+		expected bool
+	}{
+		{"1968-01-01", "1968-01-01", true},
+		{"1980-07-04", "1980-07-04", true},
+		{"1995-06-30", "1995-06-30", true},
+		{"1995-07-01", "2001-01-01", false},
+		{"1960-01-01", "1967-12-31", false},
+		{"1960-01-01", "2001-01-01", true},
+	}
+
+	for _, test := range tests {
+
+		start, _ := time.Parse("2006-01-02", test.start)
+		end, _ := time.Parse("2006-01-02", test.end)
+
+		ok, err := a.InServiceDuring(start, end)
+
+		if err != nil {
+			t.Fatalf("Failed to determine whether %s was in service, %v", a, err)
+		}
+
+		if ok != test.expected {
+			t.Fatalf("Expected in service between %s and %s to be %t", test.start, test.end, test.expected)
+		}
+	}
+
+	open := &Aircraft{
+		Inception: "2016",
+		Cessation: "..",
+	}
+
+	ok, err := open.InServiceOn(time.Now())
+
+	if err != nil {
+		t.Fatalf("Failed to determine whether %s was in service, %v", open, err)
+	}
+
+	if !ok {
+		t.Fatalf("Expected open-ended aircraft to be in service")
+	}
+
+	undated := &Aircraft{}
+
+	ok, err = undated.InServiceOn(time.Now())
+
+	if err != nil {
+		t.Fatalf("Failed to determine whether %s was in service, %v", undated, err)
+	}
+
+	if ok {
+		t.Fatalf("Expected undated aircraft to not be in service")
+	}
+
+	invalid := &Aircraft{
+		Inception: "not a date",
+	}
+
+	_, err = invalid.InServiceOn(time.Now())
+
+	if err == nil {
+		t.Fatalf("Expected invalid inception date to fail")
+	}
+}
+
+func TestSFOMuseumLookupFindInService(t *testing.T) {
+
+	ctx := context.Background()
+
+	a := &Aircraft{
+		WOFID:       9990000045,
+		Name:        "Dated aircraft",
+		SFOMuseumID: -1,
+		Inception:   "1927",
+		Cessation:   "1931",
+	}
+
+	sfom_lu := newTestLookup(t, ctx, a)
+
+	on, _ := time.Parse("2006-01-02", "1929-10-29")
+
+	results, err := sfom_lu.FindInServiceOn(ctx, on)
+
+	if err != nil {
+		t.Fatalf("Failed to find aircraft in service, %v", err)
+	}
+
+	found := false
+
+	for _, r := range results {
+
+		if r.WOFID == a.WOFID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		t.Fatalf("Expected %s to be in service on %v", a, on)
+	}
+
+	start, _ := time.Parse("2006-01-02", "1940-01-01")
+	end, _ := time.Parse("2006-01-02", "1950-01-01")
+
+	results, err = sfom_lu.FindInServiceDuring(ctx, start, end)
+
+	if err != nil {
+		t.Fatalf("Failed to find aircraft in service, %v", err)
+	}
+
+	for _, r := range results {
+
+		if r.WOFID == a.WOFID {
+			t.Fatalf("Expected %s to not be in service between %v and %v", a, start, end)
+		}
+	}
+
+	_, err = sfom_lu.FindInServiceDuring(ctx, end, start)
+
+	if err == nil {
+		t.Fatalf("Expected invalid date range to fail")
+	}
+}
@@ -0,0 +1,40 @@
+package sfomuseum
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/data"
+	"io"
+)
+
+// LoadEmbeddedData will return the list of `Aircraft` derived from precompiled (embedded) data in `data/sfomuseum.json`.
+func LoadEmbeddedData(ctx context.Context) ([]*Aircraft, error) {
+
+	fh, err := data.Open("sfomuseum.json")
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
+	}
+
+	defer fh.Close()
+
+	return ReadAircraftData(ctx, fh)
+}
+
+// ReadAircraftData will return the list of `Aircraft` decoded from `r` which is expected to be formatted in the same way as the
+// precompiled (embedded) data in `data/sfomuseum.json`, either as a JSON array or as JSON Lines.
+func ReadAircraftData(ctx context.Context, r io.Reader) ([]*Aircraft, error) {
+
+	aircraft_list := make([]*Aircraft, 0)
+
+	err := readAircraft(ctx, r, func(data *Aircraft) error {
+		aircraft_list = append(aircraft_list, data)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode data, %w", err)
+	}
+
+	return aircraft_list, nil
+}
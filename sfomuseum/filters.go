@@ -0,0 +1,124 @@
+package sfomuseum
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-json-query"
+	"net/url"
+)
+
+// The default mode for evaluating include queries: a feature must match all of them to be included.
+const DEFAULT_INCLUDE_MODE string = query.QUERYSET_MODE_ALL
+
+// The default mode for evaluating exclude queries: a feature matching any of them is excluded.
+const DEFAULT_EXCLUDE_MODE string = query.QUERYSET_MODE_ANY
+
+// NewQuerySet will return a new `query.QuerySet` instance derived from one or more {PATH}={REGULAR_EXPRESSION} strings. `mode` is
+// one of `query.QUERYSET_MODE_ALL` or `query.QUERYSET_MODE_ANY`; if empty `query.QUERYSET_MODE_ALL` is assumed.
+func NewQuerySet(mode string, queries ...string) (*query.QuerySet, error) {
+
+	switch mode {
+	case "":
+		mode = query.QUERYSET_MODE_ALL
+	case query.QUERYSET_MODE_ALL, query.QUERYSET_MODE_ANY:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid query mode '%s'", mode)
+	}
+
+	var query_flags query.QueryFlags
+
+	for _, str := range queries {
+
+		err := query_flags.Set(str)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid query '%s', %w", str, err)
+		}
+	}
+
+	qs := &query.QuerySet{
+		Queries: query_flags,
+		Mode:    mode,
+	}
+
+	return qs, nil
+}
+
+// setQueryFilters assigns the query sets defined by the `include`, `include_mode`, `exclude` and `exclude_mode` parameters in `q`, if
+// present, to `opts`. If a mode is not specified `DEFAULT_INCLUDE_MODE` or `DEFAULT_EXCLUDE_MODE` is used.
+func setQueryFilters(opts *CompileOptions, q url.Values) error {
+
+	includes := q["include"]
+
+	if len(includes) > 0 {
+
+		mode := q.Get("include_mode")
+
+		if mode == "" {
+			mode = DEFAULT_INCLUDE_MODE
+		}
+
+		qs, err := NewQuerySet(mode, includes...)
+
+		if err != nil {
+			return fmt.Errorf("Invalid ?include= parameter, %w", err)
+		}
+
+		opts.Include = qs
+	}
+
+	excludes := q["exclude"]
+
+	if len(excludes) > 0 {
+
+		mode := q.Get("exclude_mode")
+
+		if mode == "" {
+			mode = DEFAULT_EXCLUDE_MODE
+		}
+
+		qs, err := NewQuerySet(mode, excludes...)
+
+		if err != nil {
+			return fmt.Errorf("Invalid ?exclude= parameter, %w", err)
+		}
+
+		opts.Exclude = qs
+	}
+
+	return nil
+}
+
+// includeFeature returns a boolean value indicating whether the GeoJSON feature `body` matches the include and exclude query
+// filters defined in `opts`.
+func includeFeature(ctx context.Context, opts *CompileOptions, body []byte) (bool, error) {
+
+	if opts.Include != nil && len(opts.Include.Queries) > 0 {
+
+		matches, err := query.Matches(ctx, opts.Include, body)
+
+		if err != nil {
+			return false, err
+		}
+
+		if !matches {
+			return false, nil
+		}
+	}
+
+	if opts.Exclude != nil && len(opts.Exclude.Queries) > 0 {
+
+		matches, err := query.Matches(ctx, opts.Exclude, body)
+
+		if err != nil {
+			return false, err
+		}
+
+		if matches {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,194 @@
+package sfomuseum
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"strconv"
+)
+
+// The maximum depth of the family tree that will be traversed by `FindVariants`.
+const MAX_HIERARCHY_DEPTH int = 8
+
+// IsVariantOf returns a boolean value indicating whether the aircraft is a variant of the aircraft with Who's On First ID `id`, either
+// because `id` is its parent or because it belongs to `id`.
+func (a *Aircraft) IsVariantOf(id int64) bool {
+
+	if id < 1 {
+		return false
+	}
+
+	if a.ParentID == id {
+		return true
+	}
+
+	for _, other_id := range a.BelongsTo {
+
+		if other_id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindParent will return the list of parent aircraft (derived from `wof:parent_id`) of the aircraft matching `code`. Matching rules
+// are the same as `Find`. If the aircraft matching `code` have no parent, or their parent is not itself an aircraft, an empty list is
+// returned.
+func (l *SFOMuseumLookup) FindParent(ctx context.Context, code string) ([]*Aircraft, error) {
+
+	matches, err := l.findAircraft(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Aircraft, 0)
+	seen := make(map[int64]bool)
+
+	for _, a := range matches {
+
+		if a.ParentID < 1 || seen[a.ParentID] {
+			continue
+		}
+
+		seen[a.ParentID] = true
+
+		l.find(wofCode(a.ParentID), func(p *Aircraft) bool {
+			results = append(results, p)
+			return true
+		}, nil)
+	}
+
+	return results, nil
+}
+
+// FindVariants will return the list of all the variants of the aircraft (the family) matching `code`, which is to say every aircraft
+// whose `wof:parent_id` or `wof:belongsto` properties reference it and, recursively, their variants. Matching rules are the same as
+// `Find`. If the aircraft matching `code` have no variants an empty list is returned.
+func (l *SFOMuseumLookup) FindVariants(ctx context.Context, code string) ([]*Aircraft, error) {
+
+	matches, err := l.findAircraft(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Aircraft, 0)
+	seen := make(map[int64]bool)
+
+	for _, a := range matches {
+		seen[a.WOFID] = true
+	}
+
+	var walk func(*Aircraft, int)
+
+	walk = func(family *Aircraft, depth int) {
+
+		if depth >= MAX_HIERARCHY_DEPTH {
+			return
+		}
+
+		variants := make([]*Aircraft, 0)
+
+		for _, prefix := range []string{"parent:", "belongsto:"} {
+
+			l.find(prefix+strconv.FormatInt(family.WOFID, 10), func(v *Aircraft) bool {
+
+				if !seen[v.WOFID] {
+					seen[v.WOFID] = true
+					variants = append(variants, v)
+				}
+
+				return true
+			}, nil)
+		}
+
+		for _, v := range variants {
+			results = append(results, v)
+			walk(v, depth+1)
+		}
+	}
+
+	for _, a := range matches {
+		walk(a, 0)
+	}
+
+	return results, nil
+}
+
+// FindSiblings will return the list of aircraft that share a parent (derived from `wof:parent_id`) with the aircraft matching `code`,
+// excluding the aircraft matching `code` itself. Matching rules are the same as `Find`. If the aircraft matching `code` have no parent,
+// or their parent is not itself an aircraft (for example a Who's On First place), an empty list is returned.
+func (l *SFOMuseumLookup) FindSiblings(ctx context.Context, code string) ([]*Aircraft, error) {
+
+	matches, err := l.findAircraft(ctx, code)
+
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Aircraft, 0)
+	seen := make(map[int64]bool)
+
+	for _, a := range matches {
+		seen[a.WOFID] = true
+	}
+
+	for _, a := range matches {
+
+		if !l.isAircraft(a.ParentID) {
+			continue
+		}
+
+		l.find("parent:"+strconv.FormatInt(a.ParentID, 10), func(s *Aircraft) bool {
+
+			if !seen[s.WOFID] {
+				seen[s.WOFID] = true
+				results = append(results, s)
+			}
+
+			return true
+		}, nil)
+	}
+
+	return results, nil
+}
+
+// findAircraft returns the list of aircraft matching `code` or an `aircraft.NotFound` error.
+func (l *SFOMuseumLookup) findAircraft(ctx context.Context, code string) ([]*Aircraft, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]*Aircraft, 0)
+
+	ok := l.find(code, func(a *Aircraft) bool {
+		matches = append(matches, a)
+		return true
+	}, nil)
+
+	if !ok {
+		return nil, aircraft.NotFound(code)
+	}
+
+	return matches, nil
+}
+
+// isAircraft returns a boolean value indicating whether the Who's On First ID `id` is an aircraft in the lookup's table, regardless of
+// whether it is current.
+func (l *SFOMuseumLookup) isAircraft(id int64) bool {
+
+	if id < 1 {
+		return false
+	}
+
+	return l.table.count(wofCode(id)) > 0
+}
+
+// wofCode returns the (qualified) code for the Who's On First ID `id`.
+func wofCode(id int64) string {
+	return "wof:" + strconv.FormatInt(id, 10)
+}
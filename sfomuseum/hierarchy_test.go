@@ -0,0 +1,161 @@
+package sfomuseum
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestSFOMuseumLookupHierarchy(t *testing.T) {
+
+	ctx := context.Background()
+
+	family := &Aircraft{
+		WOFID:       9990000048,
+		Name:        "Example family",
+		SFOMuseumID: -1,
+	}
+
+	variant_a := &Aircraft{
+		WOFID:       9990000049,
+		Name:        "Example variant A",
+		SFOMuseumID: -1,
+		ParentID:    family.WOFID,
+		BelongsTo:   []int64{family.WOFID},
+	}
+
+	variant_b := &Aircraft{
+		WOFID:       9990000050,
+		Name:        "Example variant B",
+		SFOMuseumID: -1,
+		ParentID:    family.WOFID,
+		BelongsTo:   []int64{family.WOFID},
+	}
+
+	sub_variant := &Aircraft{
+		WOFID:       9990000051,
+		Name:        "Example variant A-1",
+		SFOMuseumID: -1,
+		ParentID:    variant_a.WOFID,
+		BelongsTo:   []int64{variant_a.WOFID, family.WOFID},
+	}
+
+	sfom_lu := newTestLookup(t, ctx, family, variant_a, variant_b, sub_variant)
+
+	ids := func(list []*Aircraft) []int64 {
+
+		ids := make([]int64, len(list))
+
+		for i, a := range list {
+			ids[i] = a.WOFID
+		}
+
+		sort.Slice(ids, func(i int, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	equals := func(a []int64, b []int64) bool {
+
+		if len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+
+			if a[i] != b[i] {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	parents, err := sfom_lu.FindParent(ctx, "wof:9990000051")
+
+	if err != nil {
+		t.Fatalf("Failed to find parent, %v", err)
+	}
+
+	if !equals(ids(parents), []int64{variant_a.WOFID}) {
+		t.Fatalf("Invalid parent for %s, %v", sub_variant, ids(parents))
+	}
+
+	variants, err := sfom_lu.FindVariants(ctx, "wof:9990000048")
+
+	if err != nil {
+		t.Fatalf("Failed to find variants, %v", err)
+	}
+
+	if !equals(ids(variants), []int64{variant_a.WOFID, variant_b.WOFID, sub_variant.WOFID}) {
+		t.Fatalf("Invalid variants for %s, %v", family, ids(variants))
+	}
+
+	siblings, err := sfom_lu.FindSiblings(ctx, "wof:9990000049")
+
+	if err != nil {
+		t.Fatalf("Failed to find siblings, %v", err)
+	}
+
+	if !equals(ids(siblings), []int64{variant_b.WOFID}) {
+		t.Fatalf("Invalid siblings for %s, %v", variant_a, ids(siblings))
+	}
+
+	parents, err = sfom_lu.FindParent(ctx, "wof:9990000048")
+
+	if err != nil {
+		t.Fatalf("Failed to find parent, %v", err)
+	}
+
+	if len(parents) != 0 {
+		t.Fatalf("Expected %s to have no parent", family)
+	}
+
+	if !sub_variant.IsVariantOf(family.WOFID) || family.IsVariantOf(sub_variant.WOFID) {
+		t.Fatalf("Invalid IsVariantOf results")
+	}
+}
+
+func TestSFOMuseumLookupHierarchyNonAircraftParent(t *testing.T) {
+
+	ctx := context.Background()
+
+	// Aircraft parented by a place (for example SFO) rather than another aircraft are not a family
+
+	place_id := int64(102527513)
+
+	aircraft_a := &Aircraft{
+		WOFID:       9990000052,
+		Name:        "Example aircraft A",
+		SFOMuseumID: -1,
+		ParentID:    place_id,
+	}
+
+	aircraft_b := &Aircraft{
+		WOFID:       9990000053,
+		Name:        "Example aircraft B",
+		SFOMuseumID: -1,
+		ParentID:    place_id,
+	}
+
+	sfom_lu := newTestLookup(t, ctx, aircraft_a, aircraft_b)
+
+	siblings, err := sfom_lu.FindSiblings(ctx, "wof:9990000052")
+
+	if err != nil {
+		t.Fatalf("Failed to find siblings, %v", err)
+	}
+
+	if len(siblings) != 0 {
+		t.Fatalf("Expected %s to have no siblings, got %d", aircraft_a, len(siblings))
+	}
+
+	parents, err := sfom_lu.FindParent(ctx, "wof:9990000052")
+
+	if err != nil {
+		t.Fatalf("Failed to find parent, %v", err)
+	}
+
+	if len(parents) != 0 {
+		t.Fatalf("Expected %s to have no parent, got %d", aircraft_a, len(parents))
+	}
+}
@@ -0,0 +1,61 @@
+package sfomuseum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/internal/binindex"
+	"io"
+)
+
+// WriteIndex will write a precompiled binary index of `aircraft_list`, including its lookup keys, to `wr`. The resulting index can be
+// loaded using `NewLookupFuncWithIndex` or the `sfomuseum://index?path={PATH}` URI.
+func WriteIndex(wr io.Writer, aircraft_list []*Aircraft) error {
+
+	records := make([][]byte, len(aircraft_list))
+	keys := make(map[string][]int)
+
+	for i, a := range aircraft_list {
+
+		enc, err := json.Marshal(a)
+
+		if err != nil {
+			return fmt.Errorf("Failed to marshal %s, %w", a, err)
+		}
+
+		records[i] = enc
+
+		for _, code := range LookupCodes(a) {
+
+			postings := keys[code]
+
+			if len(postings) > 0 && postings[len(postings)-1] == i {
+				continue
+			}
+
+			keys[code] = append(postings, i)
+		}
+	}
+
+	return binindex.Write(wr, records, keys)
+}
+
+// NewLookupFuncWithIndex will return an `SFOMuseumLookupFunc` function instance that, when invoked, will populate an `aircraft.Lookup` instance
+// with the precompiled binary index stored in `path` (as produced by `WriteIndex`). If `use_mmap` is true the index will be memory-mapped
+// rather than read in to memory, where supported. Records are only decoded when they are first returned by a query. Lookups do not have
+// a `Close` method so the index, and its memory mapping, is kept open for the life of the process.
+func NewLookupFuncWithIndex(ctx context.Context, path string, use_mmap bool) SFOMuseumLookupFunc {
+
+	lookup_func := func(ctx context.Context) (*LookupTable, error) {
+
+		idx, err := binindex.Open(path, use_mmap)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open index %s, %w", path, err)
+		}
+
+		return newLookupTableWithIndex(idx), nil
+	}
+
+	return lookup_func
+}
@@ -2,37 +2,47 @@ package sfomuseum
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/sfomuseum/go-sfomuseum-aircraft"
 	"github.com/sfomuseum/go-sfomuseum-aircraft/data"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/internal/jsonstream"
 	"io"
 	_ "log"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
 	"sync"
-	"sync/atomic"
 )
 
-var lookup_table *sync.Map
-var lookup_idx int64
+// lookup_table is the lookup table for the precompiled (embedded) data which is shared by all the lookups that use it.
+var lookup_table *LookupTable
 
-var lookup_init sync.Once
-var lookup_init_err error
+// lookup_mu guards the initialization of lookup_table
+var lookup_mu sync.Mutex
 
-type SFOMuseumLookupFunc func(context.Context)
+// SFOMuseumLookupFunc is a function that, when invoked, returns a lookup table populated with aircraft data.
+type SFOMuseumLookupFunc func(context.Context) (*LookupTable, error)
 
 type SFOMuseumLookup struct {
 	aircraft.Lookup
+	table              *LookupTable
+	include_noncurrent bool
+}
+
+// The maximum number of `wof:superseded_by` redirects that will be followed from any one record.
+const MAX_REDIRECTS int = 8
+
+// Redirect describes a superseded record that was followed to the records that supersede it.
+type Redirect struct {
+	// The Who's On First ID of the superseded record.
+	From int64
+	// The Who's On First IDs of the records that supersede it.
+	To []int64
 }
 
 func init() {
 	ctx := context.Background()
 	aircraft.RegisterLookup(ctx, "sfomuseum", NewLookup)
-
-	lookup_idx = int64(0)
 }
 
 // NewLookup will return an `aircraft.Lookup` instance. By default the lookup table is derived from precompiled (embedded) data in `data/sfomuseum.json`
@@ -41,6 +51,13 @@ func init() {
 // This will cause the lookup table to be derived from the data stored at https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-aircraft/main/data/sfomuseum.json. This might be desirable if there have been updates to the underlying data that are not reflected in the locally installed package's pre-compiled data.
 //	`sfomuseum://iterator?uri={URI}&source={SOURCE}`
 // This will cause the lookup table to be derived, at runtime, from data emitted by a `whosonfirst/go-whosonfirst-iterate` instance. `{URI}` should be a valid `whosonfirst/go-whosonfirst-iterate/iterator` URI and `{SOURCE}` is one or more URIs for the iterator to process.
+// Features may be filtered by adding one or more `&include={PATH}={REGULAR_EXPRESSION}` or `&exclude={PATH}={REGULAR_EXPRESSION}` parameters, with optional `&include_mode=` and `&exclude_mode=` parameters (ALL or ANY, defaulting to `DEFAULT_INCLUDE_MODE` and `DEFAULT_EXCLUDE_MODE` respectively). See `CompileOptions` for details.
+//	`sfomuseum://index?path={PATH}&mmap={BOOLEAN}`
+// This will cause the lookup table to be derived from a precompiled binary index (as produced by the `build-sfomuseum-data` tool) stored at `{PATH}`. If `{BOOLEAN}` is true the index will be memory-mapped rather than read in to memory.
+//	`sfomuseum://overlay?overrides={PATH}&lookup={LOOKUP_URI}`
+// This will cause the overrides (add, replace and suppress operations keyed on Who's On First ID) stored at `{PATH}` to be applied on top of the lookup defined by `{LOOKUP_URI}`. If `{LOOKUP_URI}` is empty the default `sfomuseum://` lookup is used. See `ReadOverrides` for details.
+// By default records that are not current (because they have been deprecated, superseded or otherwise flagged as not current) are excluded from results. Any
+// URI, other than `sfomuseum://overlay`, may include an `?include_noncurrent=true` parameter to include them.
 func NewLookup(ctx context.Context, uri string) (aircraft.Lookup, error) {
 
 	u, err := url.Parse(uri)
@@ -49,6 +66,29 @@ func NewLookup(ctx context.Context, uri string) (aircraft.Lookup, error) {
 		return nil, fmt.Errorf("Failed to parse URI, %w", err)
 	}
 
+	include_noncurrent, err := parseBool(u.Query().Get("include_noncurrent"))
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid ?include_noncurrent= parameter, %w", err)
+	}
+
+	l, err := newLookup(ctx, u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sfom_l, ok := l.(*SFOMuseumLookup)
+
+	if ok {
+		sfom_l.include_noncurrent = include_noncurrent
+	}
+
+	return l, nil
+}
+
+func newLookup(ctx context.Context, u *url.URL) (aircraft.Lookup, error) {
+
 	// Reminder: u.Scheme is used by the aircraft.Lookup constructor
 
 	switch u.Host {
@@ -59,104 +99,199 @@ func NewLookup(ctx context.Context, uri string) (aircraft.Lookup, error) {
 		iterator_uri := q.Get("uri")
 		iterator_sources := q["source"]
 
-		return NewLookupFromIterator(ctx, iterator_uri, iterator_sources...)
+		opts := &CompileOptions{}
 
-	case "github":
+		err := setQueryFilters(opts, q)
 
-		data_url := "https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-aircraft/main/data/sfomuseum.json"
-		rsp, err := http.Get(data_url)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewLookupFromIteratorWithOptions(ctx, opts, iterator_uri, iterator_sources...)
+
+	case "index":
+
+		q := u.Query()
+
+		path := q.Get("path")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?path= parameter")
+		}
+
+		use_mmap, err := parseBool(q.Get("mmap"))
 
 		if err != nil {
-			return nil, fmt.Errorf("Failed to load remote data from Github, %w", err)
+			return nil, fmt.Errorf("Invalid ?mmap= parameter, %w", err)
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, rsp.Body)
+		lookup_func := NewLookupFuncWithIndex(ctx, path, use_mmap)
 		return NewLookupWithLookupFunc(ctx, lookup_func)
 
-	default:
+	case "overlay":
+
+		q := u.Query()
+
+		path := q.Get("overrides")
+
+		if path == "" {
+			return nil, fmt.Errorf("Missing ?overrides= parameter")
+		}
+
+		lookup_uri := q.Get("lookup")
 
-		fs := data.FS
-		fh, err := fs.Open("sfomuseum.json")
+		if lookup_uri == "" {
+			lookup_uri = "sfomuseum://"
+		}
+
+		return newOverlayLookupFromPath(ctx, lookup_uri, path)
+
+	case "github":
+
+		data_url := "https://raw.githubusercontent.com/sfomuseum/go-sfomuseum-aircraft/main/data/sfomuseum.json"
+		rsp, err := http.Get(data_url)
 
 		if err != nil {
-			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
+			return nil, fmt.Errorf("Failed to load remote data from Github, %w", err)
 		}
 
-		lookup_func := NewLookupFuncWithReader(ctx, fh)
+		lookup_func := NewLookupFuncWithReader(ctx, rsp.Body)
 		return NewLookupWithLookupFunc(ctx, lookup_func)
+
+	default:
+		return newDefaultLookup(ctx)
 	}
 
 }
 
 // NewLookup will return an `SFOMuseumLookupFunc` function instance that, when invoked, will populate an `aircraft.Lookup` instance with data stored in `r`.
 // `r` will be closed when the `SFOMuseumLookupFunc` function instance is invoked.
-// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/sfomuseum.json`.
+// It is assumed that the data in `r` will be formatted in the same way as the procompiled (embedded) data stored in `data/sfomuseum.json`, either as
+// a JSON array or as JSON Lines (one record per line). Records are indexed as they are decoded. If the context passed to the `SFOMuseumLookupFunc` is
+// cancelled before all the records have been indexed the lookup will fail with the context's error.
 func NewLookupFuncWithReader(ctx context.Context, r io.ReadCloser) SFOMuseumLookupFunc {
 
-	defer r.Close()
+	lookup_func := func(ctx context.Context) (*LookupTable, error) {
 
-	var aircraft_list []*Aircraft
+		defer r.Close()
 
-	dec := json.NewDecoder(r)
-	err := dec.Decode(&aircraft_list)
+		table := NewLookupTable()
 
-	if err != nil {
+		err := readAircraft(ctx, r, func(data *Aircraft) error {
+			return appendData(ctx, table, data)
+		})
 
-		lookup_func := func(ctx context.Context) {
-			lookup_init_err = err
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load aircraft data, %w", err)
 		}
 
-		return lookup_func
+		table.compact()
+		return table, nil
 	}
 
-	return NewLookupFuncWithAircraft(ctx, aircraft_list)
+	return lookup_func
 }
 
 // NewLookup will return an `SFOMuseumLookupFunc` function instance that, when invoked, will populate an `aircraft.Lookup` instance with data stored in `aircraft_list`.
+// If the context passed to the `SFOMuseumLookupFunc` is cancelled before all the records have been indexed the lookup will fail with the context's error.
 func NewLookupFuncWithAircraft(ctx context.Context, aircraft_list []*Aircraft) SFOMuseumLookupFunc {
 
-	lookup_func := func(ctx context.Context) {
+	lookup_func := func(ctx context.Context) (*LookupTable, error) {
 
-		table := new(sync.Map)
+		table := NewLookupTable()
 
 		for _, data := range aircraft_list {
 
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				// pass
+			err := ctx.Err()
+
+			if err != nil {
+				return nil, err
 			}
 
 			appendData(ctx, table, data)
 		}
 
-		lookup_table = table
+		table.compact()
+		return table, nil
 	}
 
 	return lookup_func
 }
 
-// NewLookupWithLookupFunc will return an `aircraft.Lookup` instance derived by data compiled using `lookup_func`.
+// readAircraft will decode each `Aircraft` record in `r`, which may be a JSON array or JSON Lines, and pass it to `cb`.
+func readAircraft(ctx context.Context, r io.Reader, cb func(*Aircraft) error) error {
+
+	new_func := func() interface{} {
+		return new(Aircraft)
+	}
+
+	record_func := func(v interface{}) error {
+		return cb(v.(*Aircraft))
+	}
+
+	return jsonstream.Decode(ctx, r, new_func, record_func)
+}
+
+// NewLookupWithLookupFunc will return an `aircraft.Lookup` instance derived by data compiled using `lookup_func`. Each lookup created
+// this way has its own lookup table.
 func NewLookupWithLookupFunc(ctx context.Context, lookup_func SFOMuseumLookupFunc) (aircraft.Lookup, error) {
 
-	fn := func() {
-		lookup_func(ctx)
+	table, err := lookup_func(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l := SFOMuseumLookup{
+		table: table,
 	}
 
-	lookup_init.Do(fn)
+	return &l, nil
+}
+
+// newDefaultLookup will return an `aircraft.Lookup` instance derived from the precompiled (embedded) data in `data/sfomuseum.json`. The data is
+// only loaded once, by the first successful call, and is shared by all the lookups created this way. If loading fails, for example
+// because `ctx` was cancelled, the error is returned and the next call will try again.
+func newDefaultLookup(ctx context.Context) (aircraft.Lookup, error) {
+
+	lookup_mu.Lock()
+	defer lookup_mu.Unlock()
+
+	if lookup_table == nil {
+
+		fh, err := data.Open("sfomuseum.json")
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load local precompiled data, %w", err)
+		}
+
+		table, err := NewLookupFuncWithReader(ctx, fh)(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lookup_table = table
+	}
 
-	if lookup_init_err != nil {
-		return nil, lookup_init_err
+	l := SFOMuseumLookup{
+		table: lookup_table,
 	}
 
-	l := SFOMuseumLookup{}
 	return &l, nil
 }
 
+// NewLookupFromIterator will return an `aircraft.Lookup` instance derived from data compiled by iterating over `iterator_sources` using
+// the `whosonfirst/go-whosonfirst-iterate` URI `iterator_uri`.
 func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_sources ...string) (aircraft.Lookup, error) {
+	opts := &CompileOptions{}
+	return NewLookupFromIteratorWithOptions(ctx, opts, iterator_uri, iterator_sources...)
+}
+
+// NewLookupFromIteratorWithOptions is identical to `NewLookupFromIterator` but data is compiled with the options defined by `opts`.
+func NewLookupFromIteratorWithOptions(ctx context.Context, opts *CompileOptions, iterator_uri string, iterator_sources ...string) (aircraft.Lookup, error) {
 
-	aircraft_data, err := CompileAircraftData(ctx, iterator_uri, iterator_sources...)
+	aircraft_data, err := CompileAircraftDataWithOptions(ctx, opts, iterator_uri, iterator_sources...)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to compile aircraft data, %w", err)
@@ -166,85 +301,249 @@ func NewLookupFromIterator(ctx context.Context, iterator_uri string, iterator_so
 	return NewLookupWithLookupFunc(ctx, lookup_func)
 }
 
+// Find will return the list of aircraft matching `code` which may be an ICAO designator, a Who's On First ID, an SFO Museum aircraft ID,
+// a Wikidata ID or any concordance value. Codes may be qualified by the kind of identifier they are to avoid ambiguous matches:
+// "designator:B744", "wof:1159289915", "sfomuseum:12", "wd:id:Q6425" or, for concordances, the concordance's namespace.
+// If `code` does not match any identifier it is matched, case-insensitively, against the aircraft's `wof:name` and any preferred,
+// variant or localized names. Names may also be qualified explicitly: "name:Boeing 747-400".
+// Finding a superseded record by its Who's On First ID returns the records that supersede it instead; use `FindWithRedirects`
+// to determine whether this has happened.
 func (l *SFOMuseumLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
 
-	pointers, ok := lookup_table.Load(code)
+	results, _, err := l.FindWithRedirects(ctx, code)
+	return results, err
+}
+
+// FindWithRedirects is identical to `Find` but also returns the list of superseded records that were followed to the records that
+// supersede them.
+func (l *SFOMuseumLookup) FindWithRedirects(ctx context.Context, code string) ([]interface{}, []*Redirect, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]interface{}, 0, l.table.count(code))
+	redirects := make([]*Redirect, 0)
+
+	cb := func(a *Aircraft) bool {
+		results = append(results, a)
+		return true
+	}
+
+	redirect_cb := func(r *Redirect) {
+		redirects = append(redirects, r)
+	}
+
+	ok := l.find(code, cb, redirect_cb)
 
 	if !ok {
-		return nil, fmt.Errorf("Code '%s' not found", code)
+		return nil, nil, aircraft.NotFound(code)
 	}
 
-	aircraft := make([]interface{}, 0)
+	return results, redirects, nil
+}
+
+// FindFunc will invoke `cb` for each aircraft matching `code`, stopping if `cb` returns false. Unlike `Find` it does not allocate
+// a new list of results so it is the preferred method for performance-sensitive code. Matching rules are the same as `Find`.
+func (l *SFOMuseumLookup) FindFunc(ctx context.Context, code string, cb func(*Aircraft) bool) error {
 
-	for _, p := range pointers.([]string) {
+	err := ctx.Err()
 
-		if !strings.HasPrefix(p, "pointer:") {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
+	if err != nil {
+		return err
+	}
+
+	ok := l.find(code, cb, nil)
+
+	if !ok {
+		return aircraft.NotFound(code)
+	}
+
+	return nil
+}
+
+// find invokes `cb` for each aircraft matching `code`, honouring the lookup's options and following superseded records found by
+// their Who's On First ID. It returns false if `cb` was never invoked.
+func (l *SFOMuseumLookup) find(code string, cb func(*Aircraft) bool, redirect_cb func(*Redirect)) bool {
+
+	found := false
+
+	visit_cb := func(a *Aircraft) bool {
+
+		if a.IsSuperseded() && isWOFIDCode(code, a.WOFID) {
+			return l.follow(a, cb, redirect_cb, &found, 0)
 		}
 
-		row, ok := lookup_table.Load(p)
+		return l.visit(a, cb, &found)
+	}
+
+	ok := l.table.find(code, visit_cb)
 
-		if !ok {
-			return nil, fmt.Errorf("Invalid pointer '%s'", p)
+	// If there is no match for code try matching it as a (normalized) name
+
+	if !ok {
+
+		name_code := nameCode(code)
+
+		if name_code != "" && name_code != code {
+			l.table.find(name_code, visit_cb)
 		}
+	}
+
+	return found
+}
 
-		aircraft = append(aircraft, row.(*Aircraft))
+// follow invokes `cb` for each (current) record that supersedes `a`, recursively. It returns false if `cb` returned false.
+func (l *SFOMuseumLookup) follow(a *Aircraft, cb func(*Aircraft) bool, redirect_cb func(*Redirect), found *bool, depth int) bool {
+
+	if depth >= MAX_REDIRECTS {
+		return true
 	}
 
-	return aircraft, nil
+	if redirect_cb != nil {
+
+		r := &Redirect{
+			From: a.WOFID,
+			To:   a.SupersededBy,
+		}
+
+		redirect_cb(r)
+	}
+
+	for _, id := range a.SupersededBy {
+
+		keep_going := true
+
+		l.table.find(wofCode(id), func(s *Aircraft) bool {
+
+			if s.IsSuperseded() {
+				keep_going = l.follow(s, cb, redirect_cb, found, depth+1)
+			} else {
+				keep_going = l.visit(s, cb, found)
+			}
+
+			return keep_going
+		})
+
+		if !keep_going {
+			return false
+		}
+	}
+
+	return true
 }
 
+// visit invokes `cb` for `a` unless it should be excluded from results. It returns false if `cb` returned false.
+func (l *SFOMuseumLookup) visit(a *Aircraft, cb func(*Aircraft) bool, found *bool) bool {
+
+	if !l.include_noncurrent && !a.IsCurrent() {
+		return true
+	}
+
+	*found = true
+	return cb(a)
+}
+
+// isWOFIDCode returns a boolean value indicating whether `code` is the (unqualified or qualified) Who's On First ID `id`.
+func isWOFIDCode(code string, id int64) bool {
+
+	str_id := strconv.FormatInt(id, 10)
+	return code == str_id || code == "wof:"+str_id
+}
+
+// Append will add `data` to the lookup's table. Lookups derived from the precompiled (embedded) data share a single table so
+// records appended to one of them will be returned by all of them.
 func (l *SFOMuseumLookup) Append(ctx context.Context, data interface{}) error {
-	return appendData(ctx, lookup_table, data.(*Aircraft))
+	return appendData(ctx, l.table, data.(*Aircraft))
+}
+
+func appendData(ctx context.Context, table *LookupTable, data *Aircraft) error {
+
+	possible_codes := LookupCodes(data)
+	table.append(data, possible_codes...)
+
+	return nil
 }
 
-func appendData(ctx context.Context, table *sync.Map, data *Aircraft) error {
+// LookupCodes returns the list of codes that `data` should be indexed by. Each identifier is indexed both unqualified and
+// qualified by its kind (for example "B744" and "designator:B744") so that callers can disambiguate identifiers that might
+// otherwise collide. Sentinel values (a Who's On First ID of 0 or an SFO Museum aircraft ID less than 1) are not indexed.
+// Names are only indexed qualified and normalized (for example "name:boeing 747-400") and relationships are indexed by the
+// ID of the related record (for example "parent:1159289915").
+func LookupCodes(data *Aircraft) []string {
 
-	idx := atomic.AddInt64(&lookup_idx, 1)
+	codes := make([]string, 0)
 
-	pointer := fmt.Sprintf("pointer:%d", idx)
-	table.Store(pointer, data)
+	if data.ICAODesignator != "" {
+		codes = append(codes, data.ICAODesignator, "designator:"+data.ICAODesignator)
+	}
 
-	str_wofid := strconv.FormatInt(data.WOFID, 10)
-	str_sfomid := strconv.Itoa(data.SFOMuseumID)
+	if data.WOFID > 0 {
+		str_wofid := strconv.FormatInt(data.WOFID, 10)
+		codes = append(codes, str_wofid, "wof:"+str_wofid)
+	}
 
-	possible_codes := []string{
-		data.ICAODesignator,
-		str_wofid,
-		str_sfomid,
+	if data.SFOMuseumID > 0 {
+		str_sfomid := strconv.Itoa(data.SFOMuseumID)
+		codes = append(codes, str_sfomid, "sfomuseum:"+str_sfomid)
 	}
 
-	for _, code := range possible_codes {
+	// Wikidata IDs are not unique to a single aircraft (for example the
+	// 737-MAX 8 and 9 share an ID) so a Wikidata ID may yield multiple results
+
+	if data.WikidataID != "" {
+		codes = append(codes, data.WikidataID, "wd:id:"+data.WikidataID)
+	}
 
-		if code == "" {
+	// Concordances are indexed by their value and by their value qualified
+	// by the concordance's namespace (for example "wd:id:Q6425")
+
+	for k, v := range data.Concordances {
+
+		if v == "" {
 			continue
 		}
 
-		pointers := make([]string, 0)
-		has_pointer := false
+		codes = append(codes, v, k+":"+v)
+	}
 
-		others, ok := table.Load(code)
+	// Relationships are indexed by the ID of the related record so that
+	// "parent:{ID}" and "belongsto:{ID}" yield the variants of {ID}
+
+	if data.ParentID > 0 {
+		codes = append(codes, "parent:"+strconv.FormatInt(data.ParentID, 10))
+	}
 
-		if ok {
+	for _, id := range data.BelongsTo {
 
-			pointers = others.([]string)
+		if id > 0 {
+			codes = append(codes, "belongsto:"+strconv.FormatInt(id, 10))
 		}
+	}
 
-		for _, dupe := range pointers {
+	// Names are only indexed qualified since, once normalized, they might
+	// otherwise collide with other codes
 
-			if dupe == pointer {
-				has_pointer = true
-				break
-			}
-		}
+	for _, n := range data.AllNames() {
 
-		if has_pointer {
-			continue
+		code := nameCode(n)
+
+		if code != "" {
+			codes = append(codes, code)
 		}
+	}
+
+	return codes
+}
 
-		pointers = append(pointers, pointer)
-		table.Store(code, pointers)
+// parseBool is a wrapper around strconv.ParseBool that treats an empty string as false.
+func parseBool(str string) (bool, error) {
+
+	if str == "" {
+		return false, nil
 	}
 
-	return nil
+	return strconv.ParseBool(str)
 }
@@ -2,10 +2,26 @@ package sfomuseum
 
 import (
 	"context"
+	"fmt"
 	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
+// newTestLookup returns an `SFOMuseumLookup` instance with its own lookup table containing `aircraft_list` so that tests do not
+// modify the lookup table shared by lookups derived from the embedded data.
+func newTestLookup(t *testing.T, ctx context.Context, aircraft_list ...*Aircraft) *SFOMuseumLookup {
+
+	lu, err := NewLookupWithLookupFunc(ctx, NewLookupFuncWithAircraft(ctx, aircraft_list))
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	return lu.(*SFOMuseumLookup)
+}
+
 func TestSFOMuseumLookup(t *testing.T) {
 
 	wofid_tests := map[string]int64{
@@ -43,3 +59,251 @@ func TestSFOMuseumLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestSFOMuseumLookupConcordances(t *testing.T) {
+
+	ctx := context.Background()
+
+	a := &Aircraft{
+		WOFID:       9990000028,
+		Name:        "Test aircraft",
+		SFOMuseumID: -1,
+		Concordances: map[string]string{
+			"example:id": "example-028",
+		},
+	}
+
+	lu := newTestLookup(t, ctx, a)
+
+	for _, code := range []string{"example-028", "example:id:example-028"} {
+
+		results, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != 1 || results[0].(*Aircraft).WOFID != a.WOFID {
+			t.Fatalf("Invalid results for '%s'", code)
+		}
+	}
+}
+
+func TestSFOMuseumLookupWikidata(t *testing.T) {
+
+	wikidata_tests := map[string][]int64{
+		"Q426074":       []int64{1528104583},
+		"wd:id:Q426074": []int64{1528104583},
+		"Q139289":       []int64{1528104575, 1528104577},
+		"wd:id:Q139289": []int64{1528104575, 1528104577},
+	}
+
+	ctx := context.Background()
+
+	lu, err := aircraft.NewLookup(ctx, "sfomuseum://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	for code, wofids := range wikidata_tests {
+
+		results, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != len(wofids) {
+			t.Fatalf("Invalid results for '%s', expected %d but got %d", code, len(wofids), len(results))
+		}
+
+		for i, wofid := range wofids {
+
+			a := results[i].(*Aircraft)
+
+			if a.WOFID != wofid {
+				t.Fatalf("Invalid match for '%s', expected %d but got %d", code, wofid, a.WOFID)
+			}
+		}
+	}
+}
+
+func TestSFOMuseumLookupQualified(t *testing.T) {
+
+	wofid_tests := map[string]int64{
+		"designator:B744": 1159289915,
+		"wof:1159289915":  1159289915,
+		"sfomuseum:12":    1159289381,
+		"sfomuseum:17":    1159289391,
+	}
+
+	ctx := context.Background()
+
+	lu, err := aircraft.NewLookup(ctx, "sfomuseum://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	for code, wofid := range wofid_tests {
+
+		results, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != 1 {
+			t.Fatalf("Invalid results for '%s'", code)
+		}
+
+		a := results[0].(*Aircraft)
+
+		if a.WOFID != wofid {
+			t.Fatalf("Invalid match for '%s', expected %d but got %d", code, wofid, a.WOFID)
+		}
+	}
+
+	for _, code := range []string{"-1", "sfomuseum:-1", "wof:12"} {
+
+		_, err := lu.Find(ctx, code)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to not be found", code)
+		}
+	}
+}
+
+func TestSFOMuseumLookupSuperseded(t *testing.T) {
+
+	ctx := context.Background()
+
+	superseded := &Aircraft{
+		WOFID:          9990000041,
+		Name:           "Superseded aircraft",
+		SFOMuseumID:    -1,
+		ICAODesignator: "ZZ41",
+		SupersededBy:   []int64{9990000042},
+	}
+
+	current := &Aircraft{
+		WOFID:       9990000042,
+		Name:        "Current aircraft",
+		SFOMuseumID: -1,
+	}
+
+	deprecated := &Aircraft{
+		WOFID:       9990000043,
+		Name:        "Deprecated aircraft",
+		SFOMuseumID: -1,
+		Deprecated:  true,
+	}
+
+	lu := newTestLookup(t, ctx, superseded, current, deprecated)
+
+	for _, code := range []string{"9990000041", "wof:9990000041"} {
+
+		results, redirects, err := lu.FindWithRedirects(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != 1 || results[0].(*Aircraft).WOFID != current.WOFID {
+			t.Fatalf("Expected '%s' to redirect to %d", code, current.WOFID)
+		}
+
+		if len(redirects) != 1 || redirects[0].From != superseded.WOFID {
+			t.Fatalf("Expected '%s' to report a redirect from %d", code, superseded.WOFID)
+		}
+	}
+
+	for _, code := range []string{"ZZ41", "9990000043"} {
+
+		_, err := lu.Find(ctx, code)
+
+		if err == nil {
+			t.Fatalf("Expected non-current record '%s' to not be found", code)
+		}
+	}
+
+	noncurrent_lu := &SFOMuseumLookup{
+		table:              lu.table,
+		include_noncurrent: true,
+	}
+
+	for code, wofid := range map[string]int64{"ZZ41": superseded.WOFID, "9990000043": deprecated.WOFID} {
+
+		results, err := noncurrent_lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != 1 || results[0].(*Aircraft).WOFID != wofid {
+			t.Fatalf("Invalid results for '%s'", code)
+		}
+	}
+}
+
+func TestSFOMuseumLookupIndex(t *testing.T) {
+
+	ctx := context.Background()
+
+	// Ensure the default lookup table has been loaded so that we can verify
+	// that the index is not ignored in favour of it
+
+	_, err := aircraft.NewLookup(ctx, "sfomuseum://")
+
+	if err != nil {
+		t.Fatalf("Failed to create default lookup, %v", err)
+	}
+
+	aircraft_list := []*Aircraft{
+		&Aircraft{WOFID: 9990000033, Name: "Index aircraft", SFOMuseumID: -1, ICAODesignator: "XI33"},
+	}
+
+	path := filepath.Join(t.TempDir(), "sfomuseum.idx")
+
+	fh, err := os.Create(path)
+
+	if err != nil {
+		t.Fatalf("Failed to create index, %v", err)
+	}
+
+	err = WriteIndex(fh, aircraft_list)
+
+	if err != nil {
+		t.Fatalf("Failed to write index, %v", err)
+	}
+
+	fh.Close()
+
+	for _, use_mmap := range []bool{false, true} {
+
+		uri := fmt.Sprintf("sfomuseum://index?path=%s&mmap=%t", path, use_mmap)
+
+		lu, err := aircraft.NewLookup(ctx, uri)
+
+		if err != nil {
+			t.Fatalf("Failed to create lookup for %s, %v", uri, err)
+		}
+
+		results, err := lu.Find(ctx, "XI33")
+
+		if err != nil {
+			t.Fatalf("Failed to find XI33 in %s, %v", uri, err)
+		}
+
+		if len(results) != 1 || results[0].(*Aircraft).WOFID != 9990000033 {
+			t.Fatalf("Invalid results for XI33 in %s", uri)
+		}
+
+		_, err = lu.Find(ctx, "B744")
+
+		if err == nil {
+			t.Fatalf("Expected B744 to not be found in %s", uri)
+		}
+	}
+}
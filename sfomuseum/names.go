@@ -0,0 +1,104 @@
+package sfomuseum
+
+import (
+	"sort"
+	"strings"
+)
+
+// The prefix used to qualify name codes in the lookup table.
+const NAME_PREFIX string = "name:"
+
+// nameCode returns the (qualified) code that `name` is indexed by. Names are matched case-insensitively and with any runs of
+// whitespace collapsed to a single space. `name` may already be qualified with `NAME_PREFIX`.
+func nameCode(name string) string {
+
+	name = strings.TrimPrefix(name, NAME_PREFIX)
+	name = strings.ToLower(name)
+	name = strings.Join(strings.Fields(name), " ")
+
+	if name == "" {
+		return ""
+	}
+
+	return NAME_PREFIX + name
+}
+
+// AllNames returns the de-duplicated list of the aircraft's `wof:name` and every preferred, variant or localized name.
+func (a *Aircraft) AllNames() []string {
+
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+
+	add := func(n string) {
+
+		if n == "" || seen[n] {
+			return
+		}
+
+		seen[n] = true
+		names = append(names, n)
+	}
+
+	add(a.Name)
+
+	for _, k := range sortedNameKeys(a.Names) {
+
+		for _, n := range a.Names[k] {
+			add(n)
+		}
+	}
+
+	return names
+}
+
+// DisplayName returns the name to display for the aircraft in the first of `languages` (for example "eng" or "fra") for which
+// a name is known. For each language a preferred name (for example `name:fra_x_preferred`) is chosen over an unqualified name
+// (`name:fra`) which is chosen over a variant name (`name:fra_x_variant`). If there is no name for any of `languages` then the
+// aircraft's `wof:name` is returned.
+func (a *Aircraft) DisplayName(languages ...string) string {
+
+	for _, lang := range languages {
+
+		for _, k := range []string{lang + "_x_preferred", lang, lang + "_x_variant"} {
+
+			names, ok := a.Names[k]
+
+			if ok && len(names) > 0 && names[0] != "" {
+				return names[0]
+			}
+		}
+	}
+
+	return a.Name
+}
+
+// sortedNameKeys returns the keys of `names` with preferred names first and then in lexical order, so that the output of
+// `AllNames` is stable.
+func sortedNameKeys(names map[string][]string) []string {
+
+	keys := make([]string, 0, len(names))
+
+	for k := range names {
+		keys = append(keys, k)
+	}
+
+	rank := func(k string) int {
+
+		if strings.HasSuffix(k, "_x_preferred") {
+			return 0
+		}
+
+		return 1
+	}
+
+	sort.Slice(keys, func(i int, j int) bool {
+
+		if rank(keys[i]) != rank(keys[j]) {
+			return rank(keys[i]) < rank(keys[j])
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
@@ -0,0 +1,95 @@
+package sfomuseum
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAircraftDisplayName(t *testing.T) {
+
+	a := &Aircraft{
+		Name: "Boeing 747-400",
+		Names: map[string][]string{
+			"eng_x_preferred": []string{"Boeing 747-400"},
+			"eng_x_variant":   []string{"Jumbo Jet"},
+			"fra_x_variant":   []string{"Boeing 747 (variante)"},
+			"fra_x_preferred": []string{"Boeing 747-400 (français)"},
+			"deu_x_variant":   []string{"Jumbo"},
+		},
+	}
+
+	tests := map[string]string{
+		"fra": "Boeing 747-400 (français)",
+		"deu": "Jumbo",
+		"jpn": "Boeing 747-400",
+	}
+
+	for lang, expected := range tests {
+
+		name := a.DisplayName(lang)
+
+		if name != expected {
+			t.Fatalf("Invalid display name for '%s', expected '%s' but got '%s'", lang, expected, name)
+		}
+	}
+
+	name := a.DisplayName("jpn", "deu", "fra")
+
+	if name != "Jumbo" {
+		t.Fatalf("Invalid display name for fallback languages, got '%s'", name)
+	}
+
+	names := a.AllNames()
+
+	if len(names) != 5 {
+		t.Fatalf("Expected 5 names but got %d (%v)", len(names), names)
+	}
+
+	if names[0] != a.Name {
+		t.Fatalf("Expected first name to be '%s' but got '%s'", a.Name, names[0])
+	}
+}
+
+func TestSFOMuseumLookupNames(t *testing.T) {
+
+	ctx := context.Background()
+
+	a := &Aircraft{
+		WOFID:       9990000047,
+		Name:        "Example Aircraft 047",
+		SFOMuseumID: -1,
+		Names: map[string][]string{
+			"fra_x_preferred": []string{"Avion d'exemple"},
+			"eng_x_variant":   []string{"Example Plane"},
+		},
+	}
+
+	lu := newTestLookup(t, ctx, a)
+
+	codes := []string{
+		"Example Aircraft 047",
+		"example  aircraft 047",
+		"avion d'exemple",
+		"name:Example Plane",
+		"name:example plane",
+	}
+
+	for _, code := range codes {
+
+		results, err := lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != 1 || results[0].(*Aircraft).WOFID != a.WOFID {
+			t.Fatalf("Invalid results for '%s'", code)
+		}
+	}
+
+	_, err := lu.Find(ctx, "Example")
+
+	if err == nil {
+		t.Fatalf("Expected partial name to not be found")
+	}
+}
@@ -0,0 +1,119 @@
+package sfomuseum
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"io"
+	"os"
+	"strconv"
+)
+
+// override is the on-disk representation of an `aircraft.Override`.
+type override struct {
+	Operation string      `json:"operation"`
+	ID        json.Number `json:"id,omitempty"`
+	Record    *Aircraft   `json:"record,omitempty"`
+}
+
+// Identity returns the identity of `data` used to key overrides, which is its Who's On First ID.
+func Identity(data *Aircraft) string {
+
+	if data.WOFID < 1 {
+		return ""
+	}
+
+	return strconv.FormatInt(data.WOFID, 10)
+}
+
+// ReadOverrides will decode a JSON-encoded list of overrides from `r`. Overrides for replace and suppress operations are keyed on
+// Who's On First ID. For example:
+//
+//	[
+//		{ "operation": "replace", "id": 1159289789, "record": { "wof:id": 1159289789, "wof:name": "Boeing 727-200", ... } },
+//		{ "operation": "suppress", "id": 1159289915 },
+//		{ "operation": "add", "record": { "wof:id": 9990000001, "wof:name": "Example", ... } }
+//	]
+func ReadOverrides(r io.Reader) ([]*aircraft.Override, error) {
+
+	var list []*override
+
+	dec := json.NewDecoder(r)
+	err := dec.Decode(&list)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode overrides, %w", err)
+	}
+
+	overrides := make([]*aircraft.Override, len(list))
+
+	for i, o := range list {
+
+		ao := &aircraft.Override{
+			Operation: o.Operation,
+			ID:        o.ID.String(),
+		}
+
+		if o.Record != nil {
+			ao.Record = o.Record
+		}
+
+		overrides[i] = ao
+	}
+
+	return overrides, nil
+}
+
+// NewOverlayLookup will return an `aircraft.OverlayLookup` instance that applies the overrides read from `r` on top of `lookup`.
+func NewOverlayLookup(ctx context.Context, lookup aircraft.Lookup, r io.Reader) (*aircraft.OverlayLookup, error) {
+
+	overrides, err := ReadOverrides(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return aircraft.NewOverlayLookupWithOverrides(ctx, lookup, overrides, identity, codes)
+}
+
+func newOverlayLookupFromPath(ctx context.Context, lookup_uri string, path string) (aircraft.Lookup, error) {
+
+	lookup, err := aircraft.NewLookup(ctx, lookup_uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create lookup for %s, %w", lookup_uri, err)
+	}
+
+	fh, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open overrides, %w", err)
+	}
+
+	defer fh.Close()
+
+	return NewOverlayLookup(ctx, lookup, fh)
+}
+
+func identity(r interface{}) string {
+
+	a, ok := r.(*Aircraft)
+
+	if !ok {
+		return ""
+	}
+
+	return Identity(a)
+}
+
+func codes(r interface{}) []string {
+
+	a, ok := r.(*Aircraft)
+
+	if !ok {
+		return nil
+	}
+
+	return LookupCodes(a)
+}
@@ -0,0 +1,112 @@
+package sfomuseum
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestSFOMuseumOverlayLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	overrides := `[
+	{ "operation": "replace", "id": 1159289915, "record": { "wof:id": 1159289915, "wof:name": "Boeing 747-400 (corrected)", "sfomuseum:aircraft_id": 23, "icao:designator": "B744" } },
+	{ "operation": "suppress", "id": "1159289391" },
+	{ "operation": "add", "record": { "wof:id": 9990000101, "wof:name": "Test aircraft", "sfomuseum:aircraft_id": -1, "icao:designator": "XTST", "wd:id": "Q1", "wof:concordances": { "wd:id": "Q1" } } },
+	{ "operation": "add", "record": { "wof:id": 1528104577, "wof:name": "Boeing 737-MAX 9 (added)", "sfomuseum:aircraft_id": -1, "icao:designator": "B39M" } },
+	{ "operation": "add", "record": { "wof:id": 9990000102, "wof:name": "Test aircraft (retired)", "sfomuseum:aircraft_id": -1, "icao:designator": "XOLD", "sfomuseum:is_not_current": true } }
+]`
+
+	path := filepath.Join(t.TempDir(), "overrides.json")
+
+	err := ioutil.WriteFile(path, []byte(overrides), 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write overrides, %v", err)
+	}
+
+	q := url.Values{}
+	q.Set("overrides", path)
+
+	lu, err := aircraft.NewLookup(ctx, "sfomuseum://overlay?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create overlay lookup, %v", err)
+	}
+
+	results, err := lu.Find(ctx, "B744")
+
+	if err != nil {
+		t.Fatalf("Failed to find B744, %v", err)
+	}
+
+	if len(results) != 1 || results[0].(*Aircraft).Name != "Boeing 747-400 (corrected)" {
+		t.Fatalf("Expected corrected record for B744, got %v", results)
+	}
+
+	_, err = lu.Find(ctx, "A306")
+
+	if !aircraft.IsNotFound(err) {
+		t.Fatalf("Expected A306 to be suppressed, got %v", err)
+	}
+
+	results, err = lu.Find(ctx, "designator:XTST")
+
+	if err != nil {
+		t.Fatalf("Failed to find added record, %v", err)
+	}
+
+	if results[0].(*Aircraft).WOFID != 9990000101 {
+		t.Fatalf("Unexpected result for added record, %v", results[0])
+	}
+
+	// The Wikidata ID is also a concordance but the record should only be returned once
+
+	results, err = lu.Find(ctx, "wd:id:Q1")
+
+	if err != nil {
+		t.Fatalf("Failed to find added record by Wikidata ID, %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result for added record by Wikidata ID, got %d", len(results))
+	}
+
+	// A record added with the identity of an existing record replaces it
+
+	results, err = lu.Find(ctx, "B39M")
+
+	if err != nil {
+		t.Fatalf("Failed to find B39M, %v", err)
+	}
+
+	if len(results) != 1 || results[0].(*Aircraft).Name != "Boeing 737-MAX 9 (added)" {
+		t.Fatalf("Expected only the added record for B39M, got %v", results)
+	}
+
+	// Overlay records are returned whether or not they are current
+
+	results, err = lu.Find(ctx, "XOLD")
+
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected non-current added record to be returned, %v", err)
+	}
+
+	// The underlying lookup is not modified
+
+	base, err := aircraft.NewLookup(ctx, "sfomuseum://")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	_, err = base.Find(ctx, "A306")
+
+	if err != nil {
+		t.Fatalf("Expected A306 in underlying lookup, %v", err)
+	}
+}
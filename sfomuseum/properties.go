@@ -0,0 +1,296 @@
+package sfomuseum
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"strings"
+)
+
+// The separator string used to distinguish {PATH}={TYPE} property strings.
+const PROPERTY_SEP string = "="
+
+// The prefix for GeoJSON property paths. Property paths that do not start with this prefix are assumed to be relative to it.
+const PROPERTIES_PREFIX string = "properties."
+
+const (
+	// PROPERTY_STRING signals that a property's value should be extracted as a string.
+	PROPERTY_STRING string = "string"
+	// PROPERTY_INT signals that a property's value should be extracted as an integer (int64).
+	PROPERTY_INT string = "int"
+	// PROPERTY_FLOAT signals that a property's value should be extracted as a floating point number (float64).
+	PROPERTY_FLOAT string = "float"
+	// PROPERTY_BOOL signals that a property's value should be extracted as a boolean.
+	PROPERTY_BOOL string = "bool"
+	// PROPERTY_JSON signals that a property's value should be extracted as-is (for example a list or a dictionary).
+	PROPERTY_JSON string = "json"
+)
+
+// Property defines an additional GeoJSON property to extract in to the `Extras` map of an `Aircraft` record during compilation.
+type Property struct {
+	// A valid tidwall/gjson path for the property, relative to the feature's properties (for example "sfomuseum:manufacturer").
+	Path string
+	// The type of the property's value. If empty `PROPERTY_STRING` is assumed.
+	Type string
+}
+
+// NewProperty will return a new `Property` instance derived from `str` which is expected to take the form of {PATH} or {PATH}={TYPE}.
+// For example "wof:lastmodified=int".
+func NewProperty(str string) (*Property, error) {
+
+	path := str
+	prop_type := PROPERTY_STRING
+
+	idx := strings.LastIndex(str, PROPERTY_SEP)
+
+	if idx != -1 {
+		path = str[0:idx]
+		prop_type = str[idx+1:]
+	}
+
+	path = strings.TrimPrefix(path, PROPERTIES_PREFIX)
+
+	if path == "" {
+		return nil, fmt.Errorf("Invalid property '%s', missing path", str)
+	}
+
+	switch prop_type {
+	case PROPERTY_STRING, PROPERTY_INT, PROPERTY_FLOAT, PROPERTY_BOOL, PROPERTY_JSON:
+		// pass
+	default:
+		return nil, fmt.Errorf("Invalid property '%s', unsupported type '%s'", str, prop_type)
+	}
+
+	p := &Property{
+		Path: path,
+		Type: prop_type,
+	}
+
+	return p, nil
+}
+
+func (p *Property) String() string {
+	return p.Path + PROPERTY_SEP + p.Type
+}
+
+// Extract returns the (typed) value of the property in the GeoJSON feature `body` and a boolean value indicating whether the property
+// exists. An error is returned if the property exists but can not be represented as the property's type.
+func (p *Property) Extract(body []byte) (interface{}, bool, error) {
+
+	rsp := gjson.GetBytes(body, PROPERTIES_PREFIX+p.Path)
+
+	if !rsp.Exists() {
+		return nil, false, nil
+	}
+
+	switch p.Type {
+	case PROPERTY_INT:
+
+		if rsp.Type != gjson.Number || float64(rsp.Int()) != rsp.Num {
+			return nil, true, fmt.Errorf("Value of '%s' is not an integer", p.Path)
+		}
+
+		return rsp.Int(), true, nil
+
+	case PROPERTY_FLOAT:
+
+		if rsp.Type != gjson.Number {
+			return nil, true, fmt.Errorf("Value of '%s' is not a number", p.Path)
+		}
+
+		return rsp.Float(), true, nil
+
+	case PROPERTY_BOOL:
+
+		if rsp.Type != gjson.True && rsp.Type != gjson.False {
+			return nil, true, fmt.Errorf("Value of '%s' is not a boolean", p.Path)
+		}
+
+		return rsp.Bool(), true, nil
+
+	case PROPERTY_JSON:
+		return json.RawMessage(rsp.Raw), true, nil
+
+	default:
+		return rsp.String(), true, nil
+	}
+}
+
+// normalize returns `v`, which may be a value returned by `Extract` or the same value after it has been encoded and decoded as JSON,
+// as the type that `Extract` returns. Values of type `PROPERTY_JSON` are re-encoded so that they have the same (compact, sorted)
+// representation whether they were extracted from a feature or decoded from previously compiled data.
+func (p *Property) normalize(v interface{}) (interface{}, error) {
+
+	switch p.Type {
+	case PROPERTY_INT:
+
+		f, ok := v.(float64)
+
+		if ok {
+			return int64(f), nil
+		}
+
+		return v, nil
+
+	case PROPERTY_JSON:
+
+		raw, ok := v.(json.RawMessage)
+
+		if ok {
+
+			err := json.Unmarshal(raw, &v)
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decode value of '%s', %w", p.Path, err)
+			}
+		}
+
+		enc, err := json.Marshal(v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode value of '%s', %w", p.Path, err)
+		}
+
+		return json.RawMessage(enc), nil
+
+	default:
+		return v, nil
+	}
+}
+
+// normalizeExtras returns a copy of `extras` with the value of each property in `properties` normalized. See `Property.normalize`
+// for details.
+func normalizeExtras(properties []*Property, extras map[string]interface{}) (map[string]interface{}, error) {
+
+	if extras == nil {
+		return nil, nil
+	}
+
+	normalized := make(map[string]interface{}, len(extras))
+
+	for k, v := range extras {
+		normalized[k] = v
+	}
+
+	for _, p := range properties {
+
+		v, ok := normalized[p.Path]
+
+		if !ok {
+			continue
+		}
+
+		v, err := p.normalize(v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		normalized[p.Path] = v
+	}
+
+	return normalized, nil
+}
+
+// PropertyFlags holds one or more `Property` instances that are created using {PATH} or {PATH}={TYPE} strings.
+type PropertyFlags []*Property
+
+// Return the string value of the set of Property instances.
+func (m *PropertyFlags) String() string {
+
+	str_props := make([]string, len(*m))
+
+	for i, p := range *m {
+		str_props[i] = p.String()
+	}
+
+	return strings.Join(str_props, " ")
+}
+
+// Parse a {PATH} or {PATH}={TYPE} string and store it as one of a set of Property instances.
+func (m *PropertyFlags) Set(value string) error {
+
+	p, err := NewProperty(value)
+
+	if err != nil {
+		return err
+	}
+
+	*m = append(*m, p)
+	return nil
+}
+
+// ExtraString returns the value of the extra property `key` as a string and a boolean value indicating whether it exists.
+func (a *Aircraft) ExtraString(key string) (string, bool) {
+
+	v, ok := a.Extras[key]
+
+	if !ok {
+		return "", false
+	}
+
+	str, ok := v.(string)
+	return str, ok
+}
+
+// ExtraInt returns the value of the extra property `key` as an integer and a boolean value indicating whether it exists. Because
+// extra properties may have been decoded from JSON, numeric values of any type are converted.
+func (a *Aircraft) ExtraInt(key string) (int64, bool) {
+
+	v, ok := a.Extras[key]
+
+	if !ok {
+		return 0, false
+	}
+
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ExtraFloat returns the value of the extra property `key` as a floating point number and a boolean value indicating whether it exists.
+func (a *Aircraft) ExtraFloat(key string) (float64, bool) {
+
+	v, ok := a.Extras[key]
+
+	if !ok {
+		return 0, false
+	}
+
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ExtraBool returns the value of the extra property `key` as a boolean and a boolean value indicating whether it exists.
+func (a *Aircraft) ExtraBool(key string) (bool, bool) {
+
+	v, ok := a.Extras[key]
+
+	if !ok {
+		return false, false
+	}
+
+	b, ok := v.(bool)
+	return b, ok
+}
@@ -0,0 +1,158 @@
+package sfomuseum
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CompileFailure records a feature that failed to compile.
+type CompileFailure struct {
+	// The path of the feature that failed to compile.
+	Path string
+	// The reason the feature failed to compile.
+	Err error
+}
+
+func (f *CompileFailure) Error() string {
+	return fmt.Sprintf("Failed to compile %s, %v", f.Path, f.Err)
+}
+
+func (f *CompileFailure) Unwrap() error {
+	return f.Err
+}
+
+// CompileErrors is an aggregate of every feature that failed to compile.
+type CompileErrors []*CompileFailure
+
+func (e CompileErrors) Error() string {
+
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, len(e))
+
+	for i, f := range e {
+		messages[i] = f.Error()
+	}
+
+	return fmt.Sprintf("%d features failed to compile:\n%s", len(e), strings.Join(messages, "\n"))
+}
+
+// CompileProgress details the progress of a compilation that is underway.
+type CompileProgress struct {
+	// Seen is the number of features that have been seen.
+	Seen int
+	// Emitted is the number of `Aircraft` records that have been emitted.
+	Emitted int
+	// Skipped is the number of features that have been skipped.
+	Skipped int
+	// Failed is the number of features that have failed to compile.
+	Failed int
+}
+
+func (p *CompileProgress) String() string {
+	return fmt.Sprintf("%d features seen, %d records emitted, %d skipped, %d failed", p.Seen, p.Emitted, p.Skipped, p.Failed)
+}
+
+// CompileReport details the outcome of compiling SFO Museum aircraft data.
+type CompileReport struct {
+	// Processed is the number of features that were compiled in to `Aircraft` records, including those that were reused.
+	Processed int
+	// Reused is the number of features that had not changed since a previous compilation and whose records were reused.
+	Reused int
+	// Skipped is the number of features that were skipped because they are alternate geometries or did not match the query filters.
+	Skipped int
+	// Failed is the number of features that failed to compile.
+	Failed int
+	// Failures is the list of features that failed to compile, sorted by path.
+	Failures []*CompileFailure
+	// State is the `CompileState` needed to compile the same sources incrementally.
+	State *CompileState
+	mu    *sync.Mutex
+}
+
+func newCompileReport() *CompileReport {
+
+	r := &CompileReport{
+		Failures: make([]*CompileFailure, 0),
+		mu:       new(sync.Mutex),
+	}
+
+	return r
+}
+
+// Err returns a `CompileErrors` error listing every feature that failed to compile, or nil if there were no failures.
+func (r *CompileReport) Err() error {
+
+	if len(r.Failures) == 0 {
+		return nil
+	}
+
+	return CompileErrors(r.Failures)
+}
+
+func (r *CompileReport) String() string {
+	return fmt.Sprintf("%d processed (%d reused), %d skipped, %d failed", r.Processed, r.Reused, r.Skipped, r.Failed)
+}
+
+// Progress returns a snapshot of the progress of the compilation.
+func (r *CompileReport) Progress() *CompileProgress {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &CompileProgress{
+		Seen:    r.Processed + r.Skipped + r.Failed,
+		Emitted: r.Processed,
+		Skipped: r.Skipped,
+		Failed:  r.Failed,
+	}
+
+	return p
+}
+
+func (r *CompileReport) process() {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Processed += 1
+}
+
+func (r *CompileReport) reuse() {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Reused += 1
+}
+
+func (r *CompileReport) skip() {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Skipped += 1
+}
+
+func (r *CompileReport) fail(f *CompileFailure) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Failed += 1
+
+	// Features are iterated concurrently so keep the list of failures
+	// sorted to make reports stable across runs
+
+	idx := sort.Search(len(r.Failures), func(i int) bool {
+		return r.Failures[i].Path >= f.Path
+	})
+
+	r.Failures = append(r.Failures, nil)
+	copy(r.Failures[idx+1:], r.Failures[idx:])
+	r.Failures[idx] = f
+}
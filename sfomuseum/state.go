@@ -0,0 +1,106 @@
+package sfomuseum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// COMPILER_VERSION identifies the version of the code, and of the `Aircraft` schema, used to compile features. It is part of the
+// options fingerprint of a `CompileState` so that records compiled by a different version are never reused. It should be incremented
+// whenever the way features are compiled, or the fields of `Aircraft`, change.
+const COMPILER_VERSION string = "1"
+
+// FeatureState records the state of a single feature when it was last compiled.
+type FeatureState struct {
+	// The SHA-256 hash of the feature's contents.
+	Hash string `json:"hash"`
+	// The value of the feature's `wof:lastmodified` property, or -1 if the feature does not have one.
+	LastModified int64 `json:"lastmodified"`
+	// The Who's On First ID of the record the feature was compiled in to, or 0 if it was skipped.
+	WOFID int64 `json:"wof:id,omitempty"`
+}
+
+// CompileState records the state of every feature that was compiled, keyed by path, so that subsequent compilations only need to
+// reprocess features that have changed. See `CompileOptions` for details.
+type CompileState struct {
+	// A fingerprint of the `CompileOptions`, and the `COMPILER_VERSION`, used to compile the features. If either change the state is ignored.
+	Options string `json:"options"`
+	// The state of each feature that was compiled (or skipped), keyed by path. Features that failed to compile are not included.
+	Features map[string]*FeatureState `json:"features"`
+}
+
+func newCompileState(opts *CompileOptions) *CompileState {
+
+	s := &CompileState{
+		Options:  optionsFingerprint(opts),
+		Features: make(map[string]*FeatureState),
+	}
+
+	return s
+}
+
+// ReadCompileState will decode a JSON-encoded `CompileState` from `r`.
+func ReadCompileState(r io.Reader) (*CompileState, error) {
+
+	var s *CompileState
+
+	dec := json.NewDecoder(r)
+	err := dec.Decode(&s)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode compile state, %w", err)
+	}
+
+	if s.Features == nil {
+		s.Features = make(map[string]*FeatureState)
+	}
+
+	return s, nil
+}
+
+// Write will write a JSON-encoded representation of the state to `wr`.
+func (s *CompileState) Write(wr io.Writer) error {
+
+	enc := json.NewEncoder(wr)
+	return enc.Encode(s)
+}
+
+// hashFeature returns the hex-encoded SHA-256 hash of `body`.
+func hashFeature(body []byte) string {
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// optionsFingerprint returns a string representing the compiler version and the options in `opts` that affect the records produced
+// from any one feature.
+func optionsFingerprint(opts *CompileOptions) string {
+
+	parts := []string{
+		"version:" + COMPILER_VERSION,
+	}
+
+	for _, p := range opts.Properties {
+		parts = append(parts, "property:"+p.String())
+	}
+
+	if opts.Include != nil {
+
+		for _, q := range opts.Include.Queries {
+			parts = append(parts, fmt.Sprintf("include:%s:%s=%s", opts.Include.Mode, q.Path, q.Match))
+		}
+	}
+
+	if opts.Exclude != nil {
+
+		for _, q := range opts.Exclude.Queries {
+			parts = append(parts, fmt.Sprintf("exclude:%s:%s=%s", opts.Exclude.Mode, q.Path, q.Match))
+		}
+	}
+
+	return hashFeature([]byte(strings.Join(parts, "\n")))
+}
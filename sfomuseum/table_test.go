@@ -0,0 +1,93 @@
+package sfomuseum
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func findRows(table *LookupTable, code string) []*Aircraft {
+
+	rows := make([]*Aircraft, 0)
+
+	table.find(code, func(a *Aircraft) bool {
+		rows = append(rows, a)
+		return true
+	})
+
+	return rows
+}
+
+func TestLookupTableConcurrentAppend(t *testing.T) {
+
+	ctx := context.Background()
+	table := NewLookupTable()
+
+	writers := 16
+	records := 100
+
+	wg := new(sync.WaitGroup)
+
+	for w := 0; w < writers; w++ {
+
+		wg.Add(2)
+
+		go func(w int) {
+
+			defer wg.Done()
+
+			for i := 0; i < records; i++ {
+
+				a := &Aircraft{
+					WOFID:          int64((w * records) + i + 1),
+					Name:           fmt.Sprintf("%d-%d", w, i),
+					SFOMuseumID:    -1,
+					ICAODesignator: "T031",
+				}
+
+				appendData(ctx, table, a)
+			}
+		}(w)
+
+		go func() {
+
+			defer wg.Done()
+
+			for i := 0; i < records; i++ {
+
+				rows := findRows(table, "designator:T031")
+
+				for _, a := range rows {
+
+					if a.ICAODesignator != "T031" {
+						t.Errorf("Invalid record, %s", a)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	rows := findRows(table, "T031")
+
+	if len(rows) != writers*records {
+		t.Fatalf("Expected %d records, got %d", writers*records, len(rows))
+	}
+
+	for i := 1; i <= writers*records; i++ {
+
+		rows := findRows(table, fmt.Sprintf("wof:%d", i))
+
+		if len(rows) != 1 {
+			t.Fatalf("Expected exactly one record for %d", i)
+		}
+	}
+
+	_, _, ok := table.lookup("-1")
+
+	if ok {
+		t.Fatalf("Sentinel SFO Museum ID should not be indexed")
+	}
+}
@@ -0,0 +1,46 @@
+package static_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/aircrafttest"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	_ "github.com/sfomuseum/go-sfomuseum-aircraft/static"
+	"testing"
+)
+
+func TestStaticICAOLookupConformance(t *testing.T) {
+
+	opts := &aircrafttest.ConformanceOptions{
+		NewLookup: func(ctx context.Context) (aircraft.Lookup, error) {
+			return aircraft.NewLookup(ctx, "static://icao")
+		},
+		Codes:   []string{"B744", "designator:A320", "manufacturer:BOEING"},
+		Missing: []string{"XXXX", "designator:BOEING"},
+		NewRecord: func(i int) (string, interface{}) {
+			code := fmt.Sprintf("ZT%02d", i)
+			return code, &icao.Aircraft{Designator: code, ManufacturerCode: "EXAMPLE"}
+		},
+	}
+
+	aircrafttest.RunConformance(t, opts)
+}
+
+func TestStaticSFOMuseumLookupConformance(t *testing.T) {
+
+	opts := &aircrafttest.ConformanceOptions{
+		NewLookup: func(ctx context.Context) (aircraft.Lookup, error) {
+			return aircraft.NewLookup(ctx, "static://sfomuseum")
+		},
+		Codes:   []string{"B744", "wof:1159289391", "sfomuseum:17", "wd:id:Q139289"},
+		Missing: []string{"XXXX", "wof:0", "sfomuseum:-1"},
+		NewRecord: func(i int) (string, interface{}) {
+			code := fmt.Sprintf("ZU%02d", i)
+			return code, &sfomuseum.Aircraft{WOFID: int64(9990003000 + i), Name: code, SFOMuseumID: -1, ICAODesignator: code}
+		},
+	}
+
+	aircrafttest.RunConformance(t, opts)
+}
@@ -0,0 +1,189 @@
+// package static provides `aircraft.Lookup` implementations derived from Go source tables generated by the `build-static-data` tool.
+// Because the records and their lookup keys are compiled in to the binary there is no data to parse at startup and any errors in
+// the data are caught at compile time.
+package static
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"net/url"
+	"sync"
+)
+
+// dataset binds a generated table of records, and its precomputed keys, to the functions needed to query and extend it.
+type dataset struct {
+	keys   map[string][]int
+	record func(int) interface{}
+	codes  func(interface{}) ([]string, error)
+}
+
+type StaticLookup struct {
+	aircraft.Lookup
+	dataset       *dataset
+	mu            *sync.RWMutex
+	appended      []interface{}
+	appended_keys map[string][]int
+}
+
+func init() {
+	ctx := context.Background()
+	aircraft.RegisterLookup(ctx, "static", NewLookup)
+}
+
+// NewLookup will return an `aircraft.Lookup` instance derived from generated Go source tables. Valid URIs are:
+//	`static://icao`
+// This will cause the lookup table to be derived from the data in `icao_data.go`.
+//	`static://sfomuseum`
+// This will cause the lookup table to be derived from the data in `sfomuseum_data.go`.
+func NewLookup(ctx context.Context, uri string) (aircraft.Lookup, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse URI, %w", err)
+	}
+
+	var ds *dataset
+
+	switch u.Host {
+	case "icao":
+		ds = icaoDataset()
+	case "sfomuseum":
+		ds = sfomuseumDataset()
+	default:
+		return nil, fmt.Errorf("Unsupported dataset '%s'", u.Host)
+	}
+
+	l := &StaticLookup{
+		dataset:       ds,
+		mu:            new(sync.RWMutex),
+		appended:      make([]interface{}, 0),
+		appended_keys: make(map[string][]int),
+	}
+
+	return l, nil
+}
+
+// Find will return the list of aircraft matching `code`. Codes are matched exactly against the keys derived by `icao.LookupCodes` or
+// `sfomuseum.LookupCodes` respectively, so names must be qualified and normalized (for example "name:boeing 747-400"). Unlike the
+// `sfomuseum` lookup there is no fallback to matching unqualified names, records that are not current are not excluded and superseded
+// records are not followed.
+func (l *StaticLookup) Find(ctx context.Context, code string) ([]interface{}, error) {
+
+	err := ctx.Err()
+
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := l.dataset.keys[code]
+
+	l.mu.RLock()
+	appended := l.appended
+	appended_offsets := l.appended_keys[code]
+	l.mu.RUnlock()
+
+	if len(offsets) == 0 && len(appended_offsets) == 0 {
+		return nil, aircraft.NotFound(code)
+	}
+
+	results := make([]interface{}, 0, len(offsets)+len(appended_offsets))
+
+	for _, i := range offsets {
+		results = append(results, l.dataset.record(i))
+	}
+
+	for _, i := range appended_offsets {
+		results = append(results, appended[i])
+	}
+
+	return results, nil
+}
+
+// Append will add `data` to the lookup. Appended records are held in memory and are specific to this lookup instance.
+func (l *StaticLookup) Append(ctx context.Context, data interface{}) error {
+
+	codes, err := l.dataset.codes(data)
+
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	offset := len(l.appended)
+	l.appended = append(l.appended, data)
+
+	for _, code := range codes {
+
+		if code == "" {
+			continue
+		}
+
+		offsets := l.appended_keys[code]
+
+		if len(offsets) > 0 && offsets[len(offsets)-1] == offset {
+			continue
+		}
+
+		l.appended_keys[code] = append(offsets, offset)
+	}
+
+	return nil
+}
+
+func icaoDataset() *dataset {
+
+	record := func(i int) interface{} {
+		return &icao_aircraft[i]
+	}
+
+	codes := func(data interface{}) ([]string, error) {
+
+		a, ok := data.(*icao.Aircraft)
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid record type %T, expected *icao.Aircraft", data)
+		}
+
+		return icao.LookupCodes(a), nil
+	}
+
+	ds := &dataset{
+		keys:   icao_keys,
+		record: record,
+		codes:  codes,
+	}
+
+	return ds
+}
+
+func sfomuseumDataset() *dataset {
+
+	record := func(i int) interface{} {
+		return &sfomuseum_aircraft[i]
+	}
+
+	codes := func(data interface{}) ([]string, error) {
+
+		a, ok := data.(*sfomuseum.Aircraft)
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid record type %T, expected *sfomuseum.Aircraft", data)
+		}
+
+		return sfomuseum.LookupCodes(a), nil
+	}
+
+	ds := &dataset{
+		keys:   sfomuseum_keys,
+		record: record,
+		codes:  codes,
+	}
+
+	return ds
+}
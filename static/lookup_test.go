@@ -0,0 +1,161 @@
+package static
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"reflect"
+	"testing"
+)
+
+func TestStaticLookup(t *testing.T) {
+
+	ctx := context.Background()
+
+	sfom_lu, err := aircraft.NewLookup(ctx, "static://sfomuseum")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	wofid_tests := map[string]int64{
+		"B39M":                1528104577,
+		"sfomuseum:12":        1159289381,
+		"wof:1159289915":      1159289915,
+		"name:boeing 747-400": 1159289915,
+	}
+
+	for code, wofid := range wofid_tests {
+
+		results, err := sfom_lu.Find(ctx, code)
+
+		if err != nil {
+			t.Fatalf("Unable to find '%s', %v", code, err)
+		}
+
+		if len(results) != 1 || results[0].(*sfomuseum.Aircraft).WOFID != wofid {
+			t.Fatalf("Invalid results for '%s'", code)
+		}
+	}
+
+	icao_lu, err := aircraft.NewLookup(ctx, "static://icao")
+
+	if err != nil {
+		t.Fatalf("Failed to create lookup, %v", err)
+	}
+
+	results, err := icao_lu.Find(ctx, "designator:B744")
+
+	if err != nil {
+		t.Fatalf("Unable to find B744, %v", err)
+	}
+
+	for _, r := range results {
+
+		if r.(*icao.Aircraft).Designator != "B744" {
+			t.Fatalf("Invalid result for B744, %v", r)
+		}
+	}
+
+	err = icao_lu.Append(ctx, &icao.Aircraft{Designator: "ZZ35", ManufacturerCode: "BOEING"})
+
+	if err != nil {
+		t.Fatalf("Failed to append record, %v", err)
+	}
+
+	results, err = icao_lu.Find(ctx, "ZZ35")
+
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Failed to find appended record, %v", err)
+	}
+
+	err = icao_lu.Append(ctx, &sfomuseum.Aircraft{})
+
+	if err == nil {
+		t.Fatalf("Expected appending the wrong record type to fail")
+	}
+
+	_, err = aircraft.NewLookup(ctx, "static://example")
+
+	if err == nil {
+		t.Fatalf("Expected unsupported dataset to fail")
+	}
+}
+
+// TestStaticDataCurrent fails if the generated source tables are out of date with respect to the precompiled (embedded) data or the
+// lookup codes derived from it. Run `go run cmd/build-static-data/main.go` to regenerate them.
+func TestStaticDataCurrent(t *testing.T) {
+
+	ctx := context.Background()
+
+	icao_list, err := icao.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load ICAO data, %v", err)
+	}
+
+	if len(icao_list) != len(icao_aircraft) {
+		t.Fatalf("Generated ICAO data is stale, expected %d records but got %d", len(icao_list), len(icao_aircraft))
+	}
+
+	icao_codes := make([][]string, len(icao_list))
+
+	for i, a := range icao_list {
+
+		if !reflect.DeepEqual(*a, icao_aircraft[i]) {
+			t.Fatalf("Generated ICAO data is stale, record %d (%s) differs", i, a)
+		}
+
+		icao_codes[i] = icao.LookupCodes(a)
+	}
+
+	assertKeys(t, "ICAO", icao_keys, icao_codes)
+
+	sfom_list, err := sfomuseum.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load SFO Museum data, %v", err)
+	}
+
+	if len(sfom_list) != len(sfomuseum_aircraft) {
+		t.Fatalf("Generated SFO Museum data is stale, expected %d records but got %d", len(sfom_list), len(sfomuseum_aircraft))
+	}
+
+	sfom_codes := make([][]string, len(sfom_list))
+
+	for i, a := range sfom_list {
+
+		if !reflect.DeepEqual(*a, sfomuseum_aircraft[i]) {
+			t.Fatalf("Generated SFO Museum data is stale, record %d (%s) differs", i, a)
+		}
+
+		sfom_codes[i] = sfomuseum.LookupCodes(a)
+	}
+
+	assertKeys(t, "SFO Museum", sfomuseum_keys, sfom_codes)
+}
+
+// assertKeys fails if `keys` does not map exactly the codes in `codes` to the offsets of the records they were derived from.
+func assertKeys(t *testing.T, label string, keys map[string][]int, codes [][]string) {
+
+	expected := make(map[string][]int)
+
+	for i, record_codes := range codes {
+
+		for _, code := range record_codes {
+
+			offsets := expected[code]
+
+			if len(offsets) > 0 && offsets[len(offsets)-1] == i {
+				continue
+			}
+
+			expected[code] = append(offsets, i)
+		}
+	}
+
+	if !reflect.DeepEqual(expected, keys) {
+		t.Fatalf("Generated %s lookup keys are stale, expected %d keys but got %d", label, len(expected), len(keys))
+	}
+}
@@ -0,0 +1,368 @@
+// package suggest provides methods for suggesting ICAO designators for SFO Museum aircraft that lack one.
+package suggest
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The decision assigned to a suggestion whose best candidate scores at or above the acceptance threshold.
+const DECISION_ACCEPT string = "accept"
+
+// The decision assigned to a suggestion whose best candidate scores below the acceptance threshold.
+const DECISION_REJECT string = "reject"
+
+// Candidate is a possible ICAO designator for an SFO Museum aircraft.
+type Candidate struct {
+	Designator       string  `json:"designator"`
+	ManufacturerCode string  `json:"manufacturer_code"`
+	ModelFullName    string  `json:"model_full_name"`
+	Score            float64 `json:"score"`
+}
+
+// Suggestion is the list of candidate ICAO designators, ordered by score, for an SFO Museum aircraft.
+type Suggestion struct {
+	Aircraft   *sfomuseum.Aircraft `json:"aircraft"`
+	Candidates []*Candidate        `json:"candidates"`
+}
+
+// Options defines configuration options for suggesting ICAO designators.
+type Options struct {
+	// The maximum number of candidates to return for each aircraft.
+	MaxCandidates int
+	// The minimum score (0.0 - 1.0) a candidate must have to be included.
+	MinScore float64
+}
+
+// DefaultOptions returns an `Options` instance with sensible defaults.
+func DefaultOptions() *Options {
+
+	opts := &Options{
+		MaxCandidates: 3,
+		MinScore:      0.5,
+	}
+
+	return opts
+}
+
+// fold_replacer maps common accented characters to their ASCII equivalents so that, for example, "Aérospatiale" matches "AEROSPATIALE".
+var fold_replacer = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+	"ý", "y", "ÿ", "y",
+)
+
+// model_code_re matches letter-hyphen-digit model codes, such as "a-310" or "dh-86", in lower-cased strings.
+var model_code_re = regexp.MustCompile(`\b([a-z]{1,3})-([0-9])`)
+
+// candidateRow is an ICAO aircraft record and its pre-computed tokens.
+type candidateRow struct {
+	aircraft *icao.Aircraft
+	tokens   map[string]bool
+}
+
+// SuggestEmbeddedData will suggest ICAO designators for the aircraft in the precompiled (embedded) data in `data/sfomuseum.json`
+// using the precompiled (embedded) data in `data/icao.json`.
+func SuggestEmbeddedData(ctx context.Context, opts *Options) ([]*Suggestion, error) {
+
+	icao_aircraft, err := icao.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sfom_aircraft, err := sfomuseum.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Suggest(ctx, icao_aircraft, sfom_aircraft, opts)
+}
+
+// Suggest will score candidate ICAO designators, derived from `icao_aircraft`, for each SFO Museum aircraft in `sfom_aircraft`
+// that does not already have an ICAO designator. If `opts` is nil the options returned by `DefaultOptions` are used. Candidates are scored by comparing the tokens in `sfomuseum.Aircraft.Name` against
+// the tokens in `icao.Aircraft.ManufacturerCode` and `icao.Aircraft.ModelFullName`. Aircraft for which there are no candidates
+// are not included in the results.
+func Suggest(ctx context.Context, icao_aircraft []*icao.Aircraft, sfom_aircraft []*sfomuseum.Aircraft, opts *Options) ([]*Suggestion, error) {
+
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
+	rows := make([]*candidateRow, len(icao_aircraft))
+
+	// Index ICAO rows by their model tokens so that each SFO Museum
+	// aircraft is only compared against plausible candidates
+
+	by_token := make(map[string][]int)
+
+	for i, a := range icao_aircraft {
+
+		model_tokens := tokenize(a.ModelFullName)
+
+		tokens := make(map[string]bool)
+
+		for _, t := range model_tokens {
+			tokens[t] = true
+		}
+
+		for _, t := range tokenize(a.ManufacturerCode) {
+			tokens[t] = true
+		}
+
+		rows[i] = &candidateRow{
+			aircraft: a,
+			tokens:   tokens,
+		}
+
+		for _, t := range model_tokens {
+			by_token[t] = append(by_token[t], i)
+		}
+	}
+
+	suggestions := make([]*Suggestion, 0)
+
+	for _, a := range sfom_aircraft {
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			// pass
+		}
+
+		if a.ICAODesignator != "" {
+			continue
+		}
+
+		name_tokens := make(map[string]bool)
+
+		for _, t := range tokenize(a.Name) {
+			name_tokens[t] = true
+		}
+
+		best := make(map[string]*Candidate)
+		seen := make(map[int]bool)
+
+		for t := range name_tokens {
+
+			for _, i := range by_token[t] {
+
+				if seen[i] {
+					continue
+				}
+
+				seen[i] = true
+
+				row := rows[i]
+				score := scoreTokens(name_tokens, row.tokens)
+
+				if score < opts.MinScore {
+					continue
+				}
+
+				code := row.aircraft.Designator
+				c, ok := best[code]
+
+				if ok && c.Score >= score {
+					continue
+				}
+
+				best[code] = &Candidate{
+					Designator:       code,
+					ManufacturerCode: row.aircraft.ManufacturerCode,
+					ModelFullName:    strings.TrimSpace(row.aircraft.ModelFullName),
+					Score:            score,
+				}
+			}
+		}
+
+		if len(best) == 0 {
+			continue
+		}
+
+		candidates := make([]*Candidate, 0, len(best))
+
+		for _, c := range best {
+			candidates = append(candidates, c)
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+
+			if candidates[i].Score != candidates[j].Score {
+				return candidates[i].Score > candidates[j].Score
+			}
+
+			return candidates[i].Designator < candidates[j].Designator
+		})
+
+		if opts.MaxCandidates > 0 && len(candidates) > opts.MaxCandidates {
+			candidates = candidates[0:opts.MaxCandidates]
+		}
+
+		s := &Suggestion{
+			Aircraft:   a,
+			Candidates: candidates,
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	return suggestions, nil
+}
+
+// WriteReport will write a human-readable, tab-separated report of `suggestions` to `wr`.
+func WriteReport(wr io.Writer, suggestions []*Suggestion) error {
+
+	for _, s := range suggestions {
+
+		_, err := fmt.Fprintf(wr, "%d\t%s\n", s.Aircraft.WOFID, s.Aircraft.Name)
+
+		if err != nil {
+			return err
+		}
+
+		for _, c := range s.Candidates {
+
+			_, err := fmt.Fprintf(wr, "\t%s\t%.3f\t%s %s\n", c.Designator, c.Score, c.ManufacturerCode, c.ModelFullName)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteDecisions will write a CSV document to `wr` containing the best candidate for each suggestion in `suggestions` and a decision
+// column for catalogers to review. The decision is pre-populated with `DECISION_ACCEPT` if the candidate's score is greater than or
+// equal to `accept_threshold` and `DECISION_REJECT` otherwise.
+func WriteDecisions(wr io.Writer, suggestions []*Suggestion, accept_threshold float64) error {
+
+	csv_wr := csv.NewWriter(wr)
+
+	header := []string{
+		"wof:id",
+		"wof:name",
+		"icao:designator",
+		"icao:manufacturer_code",
+		"icao:model_full_name",
+		"score",
+		"decision",
+	}
+
+	err := csv_wr.Write(header)
+
+	if err != nil {
+		return err
+	}
+
+	for _, s := range suggestions {
+
+		if len(s.Candidates) == 0 {
+			continue
+		}
+
+		c := s.Candidates[0]
+
+		decision := DECISION_REJECT
+
+		if c.Score >= accept_threshold {
+			decision = DECISION_ACCEPT
+		}
+
+		row := []string{
+			strconv.FormatInt(s.Aircraft.WOFID, 10),
+			s.Aircraft.Name,
+			c.Designator,
+			c.ManufacturerCode,
+			c.ModelFullName,
+			strconv.FormatFloat(c.Score, 'f', 3, 64),
+			decision,
+		}
+
+		err := csv_wr.Write(row)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	csv_wr.Flush()
+	return csv_wr.Error()
+}
+
+// scoreTokens returns the weighted Dice coefficient of `a` and `b`. Tokens containing digits, which typically
+// identify a specific model, are weighted twice as heavily as other tokens.
+func scoreTokens(a map[string]bool, b map[string]bool) float64 {
+
+	var shared float64
+	var total float64
+
+	for t := range a {
+
+		w := weightToken(t)
+		total += w
+
+		if b[t] {
+			shared += w
+		}
+	}
+
+	for t := range b {
+		total += weightToken(t)
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return (2 * shared) / total
+}
+
+func weightToken(t string) float64 {
+
+	for _, r := range t {
+
+		if unicode.IsDigit(r) {
+			return 2.0
+		}
+	}
+
+	return 1.0
+}
+
+// tokenize returns the lower-cased, alphanumeric tokens in `str` with common accented characters folded to their ASCII equivalents
+// and letter-hyphen-digit model codes joined together.
+func tokenize(str string) []string {
+
+	fn := func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+
+	str = strings.ToLower(str)
+	str = fold_replacer.Replace(str)
+
+	// Join letter-hyphen-digit model codes (for example "A-310") so that they
+	// yield the same token as their unhyphenated equivalents ("A310-300")
+
+	str = model_code_re.ReplaceAllString(str, "$1$2")
+
+	return strings.FieldsFunc(str, fn)
+}
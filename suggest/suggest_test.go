@@ -0,0 +1,125 @@
+package suggest
+
+import (
+	"bytes"
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"strings"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+
+	ctx := context.Background()
+
+	icao_aircraft := []*icao.Aircraft{
+		&icao.Aircraft{Designator: "B722", ManufacturerCode: "BOEING", ModelFullName: "727-200"},
+		&icao.Aircraft{Designator: "B721", ManufacturerCode: "BOEING", ModelFullName: "727-100"},
+		&icao.Aircraft{Designator: "AS32", ManufacturerCode: "AEROSPATIALE", ModelFullName: "AS-332 Super Puma"},
+	}
+
+	sfom_aircraft := []*sfomuseum.Aircraft{
+		&sfomuseum.Aircraft{WOFID: 1, Name: "Boeing 727-200"},
+		&sfomuseum.Aircraft{WOFID: 2, Name: "Aérospatiale AS-332 Super Puma"},
+		&sfomuseum.Aircraft{WOFID: 3, Name: "Boeing 727-200", ICAODesignator: "B722"},
+		&sfomuseum.Aircraft{WOFID: 4, Name: "Wright Flyer"},
+	}
+
+	suggestions, err := Suggest(ctx, icao_aircraft, sfom_aircraft, DefaultOptions())
+
+	if err != nil {
+		t.Fatalf("Failed to derive suggestions, %v", err)
+	}
+
+	expected := map[int64]string{
+		1: "B722",
+		2: "AS32",
+	}
+
+	if len(suggestions) != len(expected) {
+		t.Fatalf("Expected %d suggestions, got %d", len(expected), len(suggestions))
+	}
+
+	for _, s := range suggestions {
+
+		code, ok := expected[s.Aircraft.WOFID]
+
+		if !ok {
+			t.Fatalf("Unexpected suggestion for %d", s.Aircraft.WOFID)
+		}
+
+		if s.Candidates[0].Designator != code {
+			t.Fatalf("Expected %s for %d, got %s", code, s.Aircraft.WOFID, s.Candidates[0].Designator)
+		}
+
+		if s.Candidates[0].Score != 1.0 {
+			t.Fatalf("Expected perfect score for %d, got %f", s.Aircraft.WOFID, s.Candidates[0].Score)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	err = WriteDecisions(&buf, suggestions, 0.8)
+
+	if err != nil {
+		t.Fatalf("Failed to write decisions, %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines in decisions, got %d", len(lines))
+	}
+
+	if !strings.HasSuffix(lines[1], DECISION_ACCEPT) {
+		t.Fatalf("Expected suggestion to be accepted, got '%s'", lines[1])
+	}
+}
+
+func TestSuggestModelCodes(t *testing.T) {
+
+	ctx := context.Background()
+
+	icao_aircraft := []*icao.Aircraft{
+		&icao.Aircraft{Designator: "A310", ManufacturerCode: "AIRBUS", ModelFullName: "A-310"},
+		&icao.Aircraft{Designator: "A333", ManufacturerCode: "AIRBUS", ModelFullName: "A-330-300"},
+		&icao.Aircraft{Designator: "A343", ManufacturerCode: "AIRBUS", ModelFullName: "A-340-300"},
+	}
+
+	sfom_aircraft := []*sfomuseum.Aircraft{
+		&sfomuseum.Aircraft{WOFID: 1, Name: "Airbus A310-300"},
+	}
+
+	// Nil options should use the defaults rather than panicking
+
+	suggestions, err := Suggest(ctx, icao_aircraft, sfom_aircraft, nil)
+
+	if err != nil {
+		t.Fatalf("Failed to derive suggestions, %v", err)
+	}
+
+	if len(suggestions) != 1 {
+		t.Fatalf("Expected 1 suggestion, got %d", len(suggestions))
+	}
+
+	if suggestions[0].Candidates[0].Designator != "A310" {
+		t.Fatalf("Expected A310 to be the best candidate, got %s", suggestions[0].Candidates[0].Designator)
+	}
+
+	tests := map[string]string{
+		"A-310":           "a310",
+		"Airbus A310-300": "airbus a310 300",
+		"DH-86 Express":   "dh86 express",
+		"727-200":         "727 200",
+	}
+
+	for str, expected := range tests {
+
+		tokens := strings.Join(tokenize(str), " ")
+
+		if tokens != expected {
+			t.Fatalf("Expected '%s' to be tokenized as '%s', got '%s'", str, expected, tokens)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// package validate provides methods for checking the consistency of the SFO Museum and ICAO aircraft datasets.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"io"
+	"regexp"
+	"sort"
+)
+
+// re_designator matches a valid ICAO aircraft type designator: two to four upper-case characters the first of which is a letter.
+var re_designator = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,3}$`)
+
+// re_wikidata matches a valid Wikidata item identifier.
+var re_wikidata = regexp.MustCompile(`^Q[1-9][0-9]*$`)
+
+// Report contains the results of comparing the SFO Museum and ICAO aircraft datasets.
+type Report struct {
+	// MissingDesignators are the SFO Museum aircraft whose ICAO designator is not present in the ICAO data.
+	MissingDesignators []*sfomuseum.Aircraft `json:"missing_designators"`
+	// SharedDesignators are the ICAO designators that map to more than one SFO Museum aircraft.
+	SharedDesignators map[string][]*sfomuseum.Aircraft `json:"shared_designators"`
+	// MalformedDesignators are the SFO Museum aircraft whose ICAO designator is not well-formed.
+	MalformedDesignators []*sfomuseum.Aircraft `json:"malformed_designators"`
+	// MalformedWikidataIDs are the SFO Museum aircraft whose Wikidata ID is not well-formed.
+	MalformedWikidataIDs []*sfomuseum.Aircraft `json:"malformed_wikidata_ids"`
+}
+
+// OK returns a boolean value indicating whether no problems were reported.
+func (r *Report) OK() bool {
+	return len(r.MissingDesignators) == 0 && len(r.SharedDesignators) == 0 && len(r.MalformedDesignators) == 0 && len(r.MalformedWikidataIDs) == 0
+}
+
+// Write will write a human-readable version of r to wr.
+func (r *Report) Write(wr io.Writer) error {
+
+	for _, a := range r.MissingDesignators {
+
+		_, err := fmt.Fprintf(wr, "missing designator\t%s\t%s\n", a.ICAODesignator, a)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	designators := make([]string, 0, len(r.SharedDesignators))
+
+	for code := range r.SharedDesignators {
+		designators = append(designators, code)
+	}
+
+	sort.Strings(designators)
+
+	for _, code := range designators {
+
+		for _, a := range r.SharedDesignators[code] {
+
+			_, err := fmt.Fprintf(wr, "shared designator\t%s\t%s\n", code, a)
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, a := range r.MalformedDesignators {
+
+		_, err := fmt.Fprintf(wr, "malformed designator\t%s\t%s\n", a.ICAODesignator, a)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, a := range r.MalformedWikidataIDs {
+
+		_, err := fmt.Fprintf(wr, "malformed wikidata id\t%s\t%s\n", a.WikidataID, a)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateEmbeddedData will compare the precompiled (embedded) data in `data/sfomuseum.json` against `data/icao.json`.
+func ValidateEmbeddedData(ctx context.Context) (*Report, error) {
+
+	icao_aircraft, err := icao.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sfom_aircraft, err := sfomuseum.LoadEmbeddedData(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Validate(ctx, icao_aircraft, sfom_aircraft)
+}
+
+// Validate will compare the SFO Museum aircraft in `sfom_aircraft` against the ICAO aircraft in `icao_aircraft`.
+func Validate(ctx context.Context, icao_aircraft []*icao.Aircraft, sfom_aircraft []*sfomuseum.Aircraft) (*Report, error) {
+
+	designators := make(map[string]bool)
+
+	for _, a := range icao_aircraft {
+		designators[a.Designator] = true
+	}
+
+	r := &Report{
+		MissingDesignators:   make([]*sfomuseum.Aircraft, 0),
+		SharedDesignators:    make(map[string][]*sfomuseum.Aircraft),
+		MalformedDesignators: make([]*sfomuseum.Aircraft, 0),
+		MalformedWikidataIDs: make([]*sfomuseum.Aircraft, 0),
+	}
+
+	by_designator := make(map[string][]*sfomuseum.Aircraft)
+
+	for _, a := range sfom_aircraft {
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			// pass
+		}
+
+		if a.WikidataID != "" && !re_wikidata.MatchString(a.WikidataID) {
+			r.MalformedWikidataIDs = append(r.MalformedWikidataIDs, a)
+		}
+
+		if a.ICAODesignator == "" {
+			continue
+		}
+
+		if !re_designator.MatchString(a.ICAODesignator) {
+			r.MalformedDesignators = append(r.MalformedDesignators, a)
+			continue
+		}
+
+		by_designator[a.ICAODesignator] = append(by_designator[a.ICAODesignator], a)
+
+		if !designators[a.ICAODesignator] {
+			r.MissingDesignators = append(r.MissingDesignators, a)
+		}
+	}
+
+	for code, matches := range by_designator {
+
+		if len(matches) > 1 {
+			r.SharedDesignators[code] = matches
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,63 @@
+package validate
+
+import (
+	"context"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/icao"
+	"github.com/sfomuseum/go-sfomuseum-aircraft/sfomuseum"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+
+	ctx := context.Background()
+
+	icao_aircraft := []*icao.Aircraft{
+		&icao.Aircraft{Designator: "B744"},
+		&icao.Aircraft{Designator: "CRJ2"},
+	}
+
+	sfom_aircraft := []*sfomuseum.Aircraft{
+		&sfomuseum.Aircraft{WOFID: 1, ICAODesignator: "B744", WikidataID: "Q5830"},
+		&sfomuseum.Aircraft{WOFID: 2, ICAODesignator: "CRJ2"},
+		&sfomuseum.Aircraft{WOFID: 3, ICAODesignator: "CRJ2"},
+		&sfomuseum.Aircraft{WOFID: 4, ICAODesignator: "B747"},
+		&sfomuseum.Aircraft{WOFID: 5, ICAODesignator: "b-747", WikidataID: "5830"},
+	}
+
+	r, err := Validate(ctx, icao_aircraft, sfom_aircraft)
+
+	if err != nil {
+		t.Fatalf("Failed to validate data, %v", err)
+	}
+
+	if r.OK() {
+		t.Fatalf("Expected report to contain errors")
+	}
+
+	if len(r.MissingDesignators) != 1 || r.MissingDesignators[0].WOFID != 4 {
+		t.Fatalf("Unexpected missing designators: %v", r.MissingDesignators)
+	}
+
+	if len(r.SharedDesignators) != 1 || len(r.SharedDesignators["CRJ2"]) != 2 {
+		t.Fatalf("Unexpected shared designators: %v", r.SharedDesignators)
+	}
+
+	if len(r.MalformedDesignators) != 1 || r.MalformedDesignators[0].WOFID != 5 {
+		t.Fatalf("Unexpected malformed designators: %v", r.MalformedDesignators)
+	}
+
+	if len(r.MalformedWikidataIDs) != 1 || r.MalformedWikidataIDs[0].WOFID != 5 {
+		t.Fatalf("Unexpected malformed Wikidata IDs: %v", r.MalformedWikidataIDs)
+	}
+}
+
+func TestValidateEmbeddedData(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := ValidateEmbeddedData(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to validate embedded data, %v", err)
+	}
+}